@@ -0,0 +1,1356 @@
+package xls
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// builtin implements a worksheet function. Arguments are references, arrays or single values
+// as produced by the operand tokens; the result is a single value, a reference or an array.
+type builtin func(e *Evaluator, ctx *evalContext, args []interface{}) interface{}
+
+// builtins maps function names to their implementation. It is filled in init
+// because the functions indirectly use the evaluator, which uses the map.
+var builtins map[string]builtin
+
+func init() {
+	builtins = map[string]builtin{
+		// math and aggregation
+		"SUM":        fnSum,
+		"PRODUCT":    fnProduct,
+		"AVERAGE":    fnAverage,
+		"MIN":        fnMin,
+		"MAX":        fnMax,
+		"COUNT":      fnCount,
+		"COUNTA":     fnCountA,
+		"COUNTBLANK": fnCountBlank,
+		"SUMIF":      fnSumIf,
+		"COUNTIF":    fnCountIf,
+		"SUMPRODUCT": fnSumProduct,
+		"ABS":        math1(math.Abs),
+		"INT":        math1(math.Floor),
+		"SIGN":       math1(sign),
+		"SQRT":       math1(math.Sqrt),
+		"EXP":        math1(math.Exp),
+		"LN":         math1(math.Log),
+		"LOG10":      math1(math.Log10),
+		"LOG":        fnLog,
+		"PI":         fnPi,
+		"MOD":        fnMod,
+		"POWER":      fnPower,
+		"ROUND":      fnRound(roundHalfAway),
+		"ROUNDUP":    fnRound(roundUp),
+		"ROUNDDOWN":  fnRound(math.Trunc),
+		"TRUNC":      fnRound(math.Trunc),
+
+		// logical and information
+		"IF":        fnIf,
+		"AND":       fnAnd,
+		"OR":        fnOr,
+		"NOT":       fnNot,
+		"TRUE":      fnConst(true),
+		"FALSE":     fnConst(false),
+		"NA":        fnConst(errNA),
+		"CHOOSE":    fnChoose,
+		"ISERROR":   fnIs(func(v interface{}) bool { _, ok := v.(formulaError); return ok }),
+		"ISERR":     fnIs(func(v interface{}) bool { err, ok := v.(formulaError); return ok && err != errNA }),
+		"ISNA":      fnIs(func(v interface{}) bool { return v == errNA }),
+		"ISBLANK":   fnIs(func(v interface{}) bool { return v == nil }),
+		"ISNUMBER":  fnIs(func(v interface{}) bool { _, ok := v.(float64); return ok }),
+		"ISTEXT":    fnIs(func(v interface{}) bool { _, ok := v.(string); return ok }),
+		"ISNONTEXT": fnIs(func(v interface{}) bool { _, ok := v.(string); return !ok }),
+		"ISLOGICAL": fnIs(func(v interface{}) bool { _, ok := v.(bool); return ok }),
+
+		// lookup and reference
+		"VLOOKUP": fnLookup(false),
+		"HLOOKUP": fnLookup(true),
+		"MATCH":   fnMatch,
+		"INDEX":   fnIndex,
+		"ROWS":    fnRows,
+		"COLUMNS": fnColumns,
+		"ROW":     fnRow,
+		"COLUMN":  fnColumn,
+
+		// date and time
+		"DATE":    fnDate,
+		"TIME":    fnTime,
+		"YEAR":    datePart(func(t time.Time) int { return t.Year() }),
+		"MONTH":   datePart(func(t time.Time) int { return int(t.Month()) }),
+		"DAY":     datePart(func(t time.Time) int { return t.Day() }),
+		"HOUR":    datePart(func(t time.Time) int { return t.Hour() }),
+		"MINUTE":  datePart(func(t time.Time) int { return t.Minute() }),
+		"SECOND":  datePart(func(t time.Time) int { return t.Second() }),
+		"WEEKDAY": fnWeekday,
+		"TODAY":   fnToday,
+		"NOW":     fnNow,
+
+		// text
+		"LEN":         fnLen,
+		"LEFT":        fnLeft,
+		"RIGHT":       fnRight,
+		"MID":         fnMid,
+		"UPPER":       text1(strings.ToUpper),
+		"LOWER":       text1(strings.ToLower),
+		"PROPER":      text1(proper),
+		"TRIM":        text1(func(s string) string { return strings.Join(strings.Fields(s), " ") }),
+		"CONCATENATE": fnConcatenate,
+		"REPT":        fnRept,
+		"EXACT":       fnExact,
+		"FIND":        fnFind(false),
+		"SEARCH":      fnFind(true),
+		"SUBSTITUTE":  fnSubstitute,
+		"REPLACE":     fnReplace,
+		"VALUE":       fnValue,
+		"T":           fnT,
+		"N":           fnN,
+		"CHAR":        fnChar,
+		"CODE":        fnCode,
+	}
+}
+
+// argument helpers
+
+func arg(args []interface{}, i int) interface{} {
+	if i < len(args) {
+		return args[i]
+	}
+	return missingArg{}
+}
+
+func isMissing(args []interface{}, i int) bool {
+	_, ok := arg(args, i).(missingArg)
+	return ok
+}
+
+func (e *Evaluator) number(args []interface{}, i int, ctx *evalContext) (float64, formulaError) {
+	return toNumber(e.scalar(arg(args, i), ctx))
+}
+
+func (e *Evaluator) text(args []interface{}, i int, ctx *evalContext) (string, formulaError) {
+	value := e.scalar(arg(args, i), ctx)
+	if err, ok := value.(formulaError); ok {
+		return "", err
+	}
+	return toText(value), ""
+}
+
+// numbers collects the numbers of the arguments: numbers in references and arrays,
+// and directly passed values converted to numbers. The first error found is returned.
+func (e *Evaluator) numbers(args []interface{}) ([]float64, formulaError) {
+	var values []float64
+	var firstErr formulaError
+	for _, a := range args {
+		e.each(a, func(value interface{}, direct bool) {
+			if firstErr != "" {
+				return
+			}
+			if err, ok := value.(formulaError); ok {
+				firstErr = err
+				return
+			}
+			if n, ok := value.(float64); ok {
+				values = append(values, n)
+				return
+			}
+			if direct {
+				n, err := toNumber(value)
+				if err != "" {
+					firstErr = err
+					return
+				}
+				values = append(values, n)
+			}
+		})
+	}
+	return values, firstErr
+}
+
+// math and aggregation
+
+func fnSum(e *Evaluator, _ *evalContext, args []interface{}) interface{} {
+	values, err := e.numbers(args)
+	if err != "" {
+		return err
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum
+}
+
+func fnProduct(e *Evaluator, _ *evalContext, args []interface{}) interface{} {
+	values, err := e.numbers(args)
+	if err != "" {
+		return err
+	}
+	if len(values) == 0 {
+		return float64(0)
+	}
+	product := 1.0
+	for _, v := range values {
+		product *= v
+	}
+	return product
+}
+
+func fnAverage(e *Evaluator, _ *evalContext, args []interface{}) interface{} {
+	values, err := e.numbers(args)
+	if err != "" {
+		return err
+	}
+	if len(values) == 0 {
+		return errDiv0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func fnMin(e *Evaluator, _ *evalContext, args []interface{}) interface{} {
+	values, err := e.numbers(args)
+	if err != "" {
+		return err
+	}
+	if len(values) == 0 {
+		return float64(0)
+	}
+	result := values[0]
+	for _, v := range values[1:] {
+		result = math.Min(result, v)
+	}
+	return result
+}
+
+func fnMax(e *Evaluator, _ *evalContext, args []interface{}) interface{} {
+	values, err := e.numbers(args)
+	if err != "" {
+		return err
+	}
+	if len(values) == 0 {
+		return float64(0)
+	}
+	result := values[0]
+	for _, v := range values[1:] {
+		result = math.Max(result, v)
+	}
+	return result
+}
+
+func fnCount(e *Evaluator, _ *evalContext, args []interface{}) interface{} {
+	count := 0
+	for _, a := range args {
+		e.each(a, func(value interface{}, direct bool) {
+			if _, ok := value.(float64); ok {
+				count++
+			} else if _, ok := value.(bool); ok && direct {
+				count++
+			} else if s, ok := value.(string); ok && direct {
+				if _, ok := parseNumber(s); ok {
+					count++
+				}
+			}
+		})
+	}
+	return float64(count)
+}
+
+func fnCountA(e *Evaluator, _ *evalContext, args []interface{}) interface{} {
+	count := 0
+	for _, a := range args {
+		e.each(a, func(value interface{}, direct bool) {
+			count++
+		})
+	}
+	return float64(count)
+}
+
+func fnCountBlank(e *Evaluator, _ *evalContext, args []interface{}) interface{} {
+	ref, ok := arg(args, 0).(reference)
+	if !ok || len(ref) != 1 {
+		return errValue
+	}
+	rng := ref[0].rng
+	count := (rng.LastRow - rng.FirstRow + 1) * (rng.LastCol - rng.FirstCol + 1)
+	e.each(ref, func(value interface{}, direct bool) {
+		if s, ok := value.(string); !ok || s != "" {
+			count--
+		}
+	})
+	return float64(count)
+}
+
+func fnSumIf(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+	criteria := newCriteria(e.scalar(arg(args, 1), ctx))
+
+	values := e.toArgArray(arg(args, 0))
+	sums := values
+	if !isMissing(args, 2) {
+		sums = e.toArgArray(arg(args, 2))
+	}
+	if err, ok := values.(formulaError); ok {
+		return err
+	}
+	if err, ok := sums.(formulaError); ok {
+		return err
+	}
+
+	sum := 0.0
+	rangeValues, sumValues := values.([][]interface{}), sums.([][]interface{})
+	for r, row := range rangeValues {
+		for c, value := range row {
+			if !criteria(value) || r >= len(sumValues) || c >= len(sumValues[r]) {
+				continue
+			}
+			switch v := sumValues[r][c].(type) {
+			case float64:
+				sum += v
+			case formulaError:
+				return v
+			}
+		}
+	}
+	return sum
+}
+
+func fnCountIf(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+	criteria := newCriteria(e.scalar(arg(args, 1), ctx))
+
+	values := e.toArgArray(arg(args, 0))
+	if err, ok := values.(formulaError); ok {
+		return err
+	}
+
+	count := 0
+	for _, row := range values.([][]interface{}) {
+		for _, value := range row {
+			if criteria(value) {
+				count++
+			}
+		}
+	}
+	return float64(count)
+}
+
+func fnSumProduct(e *Evaluator, _ *evalContext, args []interface{}) interface{} {
+	var arrays [][][]interface{}
+	for _, a := range args {
+		values := e.toArgArray(a)
+		if err, ok := values.(formulaError); ok {
+			return err
+		}
+		arr := values.([][]interface{})
+		if len(arrays) > 0 && (len(arr) != len(arrays[0]) || arrayWidth(arr) != arrayWidth(arrays[0])) {
+			return errValue
+		}
+		arrays = append(arrays, arr)
+	}
+	if len(arrays) == 0 {
+		return errValue
+	}
+
+	sum := 0.0
+	for r := range arrays[0] {
+		for c := range arrays[0][r] {
+			product := 1.0
+			for _, arr := range arrays {
+				switch v := arr[r][c].(type) {
+				case float64:
+					product *= v
+				case formulaError:
+					return v
+				default:
+					// text, booleans and empty cells count as 0
+					product = 0
+				}
+			}
+			sum += product
+		}
+	}
+	return sum
+}
+
+// toArgArray converts a function argument to an array; single values become a 1x1 array.
+func (e *Evaluator) toArgArray(value interface{}) interface{} {
+	switch v := value.(type) {
+	case reference:
+		return e.toArray(v)
+	case [][]interface{}:
+		return v
+	case formulaError:
+		return v
+	case missingArg:
+		return errValue
+	}
+	return [][]interface{}{{value}}
+}
+
+func math1(fn func(float64) float64) builtin {
+	return func(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+		x, err := e.number(args, 0, ctx)
+		if err != "" {
+			return err
+		}
+		return checkNumber(fn(x))
+	}
+}
+
+func sign(x float64) float64 {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	}
+	return 0
+}
+
+func fnLog(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+	x, err := e.number(args, 0, ctx)
+	if err != "" {
+		return err
+	}
+	base := 10.0
+	if !isMissing(args, 1) {
+		if base, err = e.number(args, 1, ctx); err != "" {
+			return err
+		}
+	}
+	if x <= 0 || base <= 0 {
+		return errNum
+	}
+	if base == 1 {
+		return errDiv0
+	}
+	return math.Log(x) / math.Log(base)
+}
+
+func fnPi(*Evaluator, *evalContext, []interface{}) interface{} {
+	return math.Pi
+}
+
+func fnMod(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+	x, err := e.number(args, 0, ctx)
+	if err != "" {
+		return err
+	}
+	y, err := e.number(args, 1, ctx)
+	if err != "" {
+		return err
+	}
+	if y == 0 {
+		return errDiv0
+	}
+	// the result has the sign of the divisor
+	return x - y*math.Floor(x/y)
+}
+
+func fnPower(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+	return binaryScalar(ptgPower, e.scalar(arg(args, 0), ctx), e.scalar(arg(args, 1), ctx))
+}
+
+func fnRound(round func(float64) float64) builtin {
+	return func(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+		x, err := e.number(args, 0, ctx)
+		if err != "" {
+			return err
+		}
+		digits, err := e.number(args, 1, ctx)
+		if err != "" {
+			return err
+		}
+		scale := math.Pow(10, math.Trunc(digits))
+		// round the scaled value to 15 significant digits first, so 2.675 scales to 267.5 and not 267.49999
+		scaled, _ := toNumber(formatGeneralNumber(x * scale))
+		return round(scaled) / scale
+	}
+}
+
+func roundHalfAway(x float64) float64 {
+	return math.Round(x)
+}
+
+func roundUp(x float64) float64 {
+	if x < 0 {
+		return math.Floor(x)
+	}
+	return math.Ceil(x)
+}
+
+// logical and information
+
+func fnIf(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+	cond, err := toBool(e.scalar(arg(args, 0), ctx))
+	if err != "" {
+		return err
+	}
+	result := arg(args, 2)
+	if cond {
+		result = arg(args, 1)
+	} else if len(args) < 3 {
+		return false
+	}
+	if _, ok := result.(missingArg); ok {
+		return float64(0)
+	}
+	return result
+}
+
+func fnAnd(e *Evaluator, _ *evalContext, args []interface{}) interface{} {
+	return logical(e, args, true)
+}
+
+func fnOr(e *Evaluator, _ *evalContext, args []interface{}) interface{} {
+	return logical(e, args, false)
+}
+
+// logical combines the booleans and numbers of the arguments with AND (all) or OR.
+func logical(e *Evaluator, args []interface{}, all bool) interface{} {
+	result, found := all, false
+	var firstErr formulaError
+	for _, a := range args {
+		e.each(a, func(value interface{}, direct bool) {
+			if firstErr != "" {
+				return
+			}
+			if _, ok := value.(string); ok && !direct {
+				return
+			}
+			b, err := toBool(value)
+			if err != "" {
+				firstErr = err
+				return
+			}
+			found = true
+			if all {
+				result = result && b
+			} else {
+				result = result || b
+			}
+		})
+	}
+	if firstErr != "" {
+		return firstErr
+	}
+	if !found {
+		return errValue
+	}
+	return result
+}
+
+func fnNot(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+	b, err := toBool(e.scalar(arg(args, 0), ctx))
+	if err != "" {
+		return err
+	}
+	return !b
+}
+
+func fnConst(value interface{}) builtin {
+	return func(*Evaluator, *evalContext, []interface{}) interface{} {
+		return value
+	}
+}
+
+func fnChoose(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+	index, err := e.number(args, 0, ctx)
+	if err != "" {
+		return err
+	}
+	i := int(index)
+	if i < 1 || i >= len(args) {
+		return errValue
+	}
+	return args[i]
+}
+
+func fnIs(test func(value interface{}) bool) builtin {
+	return func(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+		return test(e.scalar(arg(args, 0), ctx))
+	}
+}
+
+// lookup and reference
+
+// fnLookup implements VLOOKUP, or HLOOKUP when horizontal is set.
+func fnLookup(horizontal bool) builtin {
+	return func(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+		value := e.scalar(arg(args, 0), ctx)
+		if err, ok := value.(formulaError); ok {
+			return err
+		}
+		table := e.toArgArray(arg(args, 1))
+		if err, ok := table.(formulaError); ok {
+			return err
+		}
+		arr := table.([][]interface{})
+		if horizontal {
+			arr = transpose(arr)
+		}
+
+		// the array of a reference is cut at the used range, the index is checked against the whole reference
+		width := arrayWidth(arr)
+		if ref, ok := arg(args, 1).(reference); ok && len(ref) == 1 {
+			width = ref[0].rng.LastCol - ref[0].rng.FirstCol + 1
+			if horizontal {
+				width = ref[0].rng.LastRow - ref[0].rng.FirstRow + 1
+			}
+		}
+
+		index, err := e.number(args, 2, ctx)
+		if err != "" {
+			return err
+		}
+		if index < 1 {
+			return errValue
+		}
+		if index > float64(width) {
+			return errRef
+		}
+
+		approximate := true
+		if !isMissing(args, 3) {
+			if approximate, err = toBool(e.scalar(arg(args, 3), ctx)); err != "" {
+				return err
+			}
+		}
+
+		keys := make([]interface{}, len(arr))
+		for i := range arr {
+			keys[i] = arr[i][0]
+		}
+		match := -1
+		if approximate {
+			match = approximateMatch(value, keys, 1)
+		} else {
+			match = exactMatch(value, keys)
+		}
+		if match < 0 {
+			return errNA
+		}
+		if index > float64(len(arr[match])) {
+			// past the used range
+			return nil
+		}
+		return arr[match][int(index)-1]
+	}
+}
+
+func fnMatch(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+	value := e.scalar(arg(args, 0), ctx)
+	if err, ok := value.(formulaError); ok {
+		return err
+	}
+	values := e.toArgArray(arg(args, 1))
+	if err, ok := values.(formulaError); ok {
+		return err
+	}
+	arr := values.([][]interface{})
+
+	var keys []interface{}
+	switch {
+	case len(arr) == 1:
+		keys = arr[0]
+	case arrayWidth(arr) == 1:
+		for _, row := range arr {
+			keys = append(keys, row[0])
+		}
+	default:
+		return errNA
+	}
+
+	matchType := 1.0
+	if !isMissing(args, 2) {
+		var err formulaError
+		if matchType, err = e.number(args, 2, ctx); err != "" {
+			return err
+		}
+	}
+
+	var match int
+	switch {
+	case matchType == 0:
+		match = exactMatch(value, keys)
+	case matchType > 0:
+		match = approximateMatch(value, keys, 1)
+	default:
+		match = approximateMatch(value, keys, -1)
+	}
+	if match < 0 {
+		return errNA
+	}
+	return float64(match + 1)
+}
+
+// exactMatch returns the index of the first key equal to value, text may use the wildcards * and ?.
+func exactMatch(value interface{}, keys []interface{}) int {
+	matches := newCriteria(value)
+	if s, ok := value.(string); ok {
+		matches = newCriteria("=" + s)
+	}
+	for i, key := range keys {
+		if matches(key) {
+			return i
+		}
+	}
+	return -1
+}
+
+// approximateMatch returns the index of the last key not greater than value in ascending keys (order 1),
+// or the last key not less than value in descending keys (order -1). Keys of another type are skipped.
+func approximateMatch(value interface{}, keys []interface{}, order int) int {
+	match := -1
+	for i, key := range keys {
+		if key == nil || !sameKind(key, value) {
+			continue
+		}
+		c := compareValues(key, value) * order
+		if c > 0 {
+			break
+		}
+		match = i
+		if c == 0 && order > 0 {
+			// keep going, duplicates resolve to the last one
+			continue
+		}
+	}
+	return match
+}
+
+func sameKind(a, b interface{}) bool {
+	switch a.(type) {
+	case float64:
+		_, ok := b.(float64)
+		return ok
+	case string:
+		_, ok := b.(string)
+		return ok
+	case bool:
+		_, ok := b.(bool)
+		return ok
+	}
+	return false
+}
+
+func transpose(arr [][]interface{}) [][]interface{} {
+	result := make([][]interface{}, arrayWidth(arr))
+	for c := range result {
+		result[c] = make([]interface{}, len(arr))
+		for r := range arr {
+			result[c][r] = arr[r][c]
+		}
+	}
+	return result
+}
+
+func fnIndex(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+	row, err := e.number(args, 1, ctx)
+	if err != "" {
+		return err
+	}
+	col := 0.0
+	if !isMissing(args, 2) {
+		if col, err = e.number(args, 2, ctx); err != "" {
+			return err
+		}
+	}
+	if row < 0 || col < 0 {
+		return errValue
+	}
+	// the bounds are checked before the conversion to int, which would overflow for huge numbers
+	if isMissing(args, 2) && isRowVector(arg(args, 0)) {
+		// INDEX(A1:E1, 3) counts columns
+		row, col = 1, row
+	}
+
+	switch v := arg(args, 0).(type) {
+	case reference:
+		if len(v) != 1 {
+			return errRef
+		}
+		rng := v[0].rng
+		if row > float64(rng.LastRow-rng.FirstRow+1) || col > float64(rng.LastCol-rng.FirstCol+1) {
+			return errRef
+		}
+		r, c := int(row), int(col)
+		result := rng
+		if r > 0 {
+			result.FirstRow, result.LastRow = rng.FirstRow+r-1, rng.FirstRow+r-1
+		}
+		if c > 0 {
+			result.FirstCol, result.LastCol = rng.FirstCol+c-1, rng.FirstCol+c-1
+		}
+		return reference{{sheet: v[0].sheet, rng: result}}
+
+	case [][]interface{}:
+		if row > float64(len(v)) || col > float64(arrayWidth(v)) {
+			return errRef
+		}
+		r, c := int(row), int(col)
+		if r == 0 || c == 0 {
+			if r == 0 && c == 0 {
+				return v
+			}
+			if r == 0 {
+				column := make([][]interface{}, len(v))
+				for i := range v {
+					column[i] = []interface{}{v[i][c-1]}
+				}
+				return column
+			}
+			return [][]interface{}{v[r-1]}
+		}
+		return v[r-1][c-1]
+
+	case formulaError:
+		return v
+	}
+
+	if row < 2 && col < 2 {
+		return arg(args, 0)
+	}
+	return errRef
+}
+
+// isRowVector tells whether value is a single-row reference or array, which INDEX indexes by column.
+func isRowVector(value interface{}) bool {
+	switch v := value.(type) {
+	case reference:
+		return len(v) == 1 && v[0].rng.FirstRow == v[0].rng.LastRow
+	case [][]interface{}:
+		return len(v) == 1
+	}
+	return false
+}
+
+func fnRows(e *Evaluator, _ *evalContext, args []interface{}) interface{} {
+	switch v := arg(args, 0).(type) {
+	case reference:
+		if len(v) != 1 {
+			return errRef
+		}
+		return float64(v[0].rng.LastRow - v[0].rng.FirstRow + 1)
+	case [][]interface{}:
+		return float64(len(v))
+	case formulaError:
+		return v
+	}
+	return float64(1)
+}
+
+func fnColumns(e *Evaluator, _ *evalContext, args []interface{}) interface{} {
+	switch v := arg(args, 0).(type) {
+	case reference:
+		if len(v) != 1 {
+			return errRef
+		}
+		return float64(v[0].rng.LastCol - v[0].rng.FirstCol + 1)
+	case [][]interface{}:
+		return float64(arrayWidth(v))
+	case formulaError:
+		return v
+	}
+	return float64(1)
+}
+
+func fnRow(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+	switch v := arg(args, 0).(type) {
+	case missingArg:
+		return float64(ctx.row + 1)
+	case reference:
+		return float64(v[0].rng.FirstRow + 1)
+	case formulaError:
+		return v
+	}
+	return errValue
+}
+
+func fnColumn(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+	switch v := arg(args, 0).(type) {
+	case missingArg:
+		return float64(ctx.col + 1)
+	case reference:
+		return float64(v[0].rng.FirstCol + 1)
+	case formulaError:
+		return v
+	}
+	return errValue
+}
+
+// date and time
+
+func fnDate(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+	var parts [3]float64
+	for i := range parts {
+		n, err := e.number(args, i, ctx)
+		if err != "" {
+			return err
+		}
+		parts[i] = math.Trunc(n)
+	}
+	year := int(parts[0])
+	if year < 1900 {
+		// years 0-1899 are counted from 1900, in both date systems
+		year += 1900
+	}
+	if year > 9999 {
+		return errNum
+	}
+
+	// months and days outside their range roll over into the neighbouring ones
+	t := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC).AddDate(0, int(parts[1])-1, int(parts[2])-1)
+	serial := excelSerial(t, e.xls.date1904)
+	if !e.xls.date1904 && year == 1900 && int(parts[1]) == 2 && int(parts[2]) == 29 {
+		// the day Excel believes exists
+		serial = 60
+	}
+	if serial < 0 {
+		return errNum
+	}
+	return serial
+}
+
+func fnTime(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+	var parts [3]float64
+	for i := range parts {
+		n, err := e.number(args, i, ctx)
+		if err != "" {
+			return err
+		}
+		parts[i] = math.Trunc(n)
+	}
+	seconds := parts[0]*3600 + parts[1]*60 + parts[2]
+	if seconds < 0 {
+		return errNum
+	}
+	return math.Mod(seconds, 86400) / 86400
+}
+
+func datePart(part func(t time.Time) int) builtin {
+	return func(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+		serial, err := e.number(args, 0, ctx)
+		if err != "" {
+			return err
+		}
+		if serial < 0 {
+			return errNum
+		}
+		return float64(part(excelTime(serial, e.xls.date1904)))
+	}
+}
+
+func fnWeekday(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+	serial, err := e.number(args, 0, ctx)
+	if err != "" {
+		return err
+	}
+	if serial < 0 {
+		return errNum
+	}
+	returnType := 1.0
+	if !isMissing(args, 1) {
+		if returnType, err = e.number(args, 1, ctx); err != "" {
+			return err
+		}
+	}
+
+	// Sunday = 0
+	day := excelWeekday(serial, e.xls.date1904)
+	switch int(returnType) {
+	case 1: // Sunday = 1 through Saturday = 7
+		return float64(day + 1)
+	case 2: // Monday = 1 through Sunday = 7
+		return float64((day+6)%7 + 1)
+	case 3: // Monday = 0 through Sunday = 6
+		return float64((day + 6) % 7)
+	}
+	return errNum
+}
+
+func fnToday(e *Evaluator, _ *evalContext, _ []interface{}) interface{} {
+	now := time.Now()
+	return math.Floor(excelSerial(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC), e.xls.date1904))
+}
+
+func fnNow(e *Evaluator, _ *evalContext, _ []interface{}) interface{} {
+	return excelSerial(time.Now(), e.xls.date1904)
+}
+
+// text
+
+func text1(fn func(string) string) builtin {
+	return func(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+		s, err := e.text(args, 0, ctx)
+		if err != "" {
+			return err
+		}
+		return fn(s)
+	}
+}
+
+func proper(s string) string {
+	runes := []rune(strings.ToLower(s))
+	for i, r := range runes {
+		if i == 0 || !unicode.IsLetter(runes[i-1]) {
+			runes[i] = unicode.ToUpper(r)
+		}
+	}
+	return string(runes)
+}
+
+func fnLen(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+	s, err := e.text(args, 0, ctx)
+	if err != "" {
+		return err
+	}
+	return float64(utf8.RuneCountInString(s))
+}
+
+func fnLeft(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+	return substring(e, ctx, args, func(runes []rune, n int) []rune { return runes[:n] })
+}
+
+func fnRight(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+	return substring(e, ctx, args, func(runes []rune, n int) []rune { return runes[len(runes)-n:] })
+}
+
+// substring implements LEFT and RIGHT, the count defaults to 1.
+func substring(e *Evaluator, ctx *evalContext, args []interface{}, cut func(runes []rune, n int) []rune) interface{} {
+	s, err := e.text(args, 0, ctx)
+	if err != "" {
+		return err
+	}
+	n := 1.0
+	if !isMissing(args, 1) {
+		if n, err = e.number(args, 1, ctx); err != "" {
+			return err
+		}
+	}
+	if n < 0 {
+		return errValue
+	}
+	runes := []rune(s)
+	// clamped as a float, a huge count would overflow int
+	return string(cut(runes, int(min(n, float64(len(runes))))))
+}
+
+func fnMid(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+	s, err := e.text(args, 0, ctx)
+	if err != "" {
+		return err
+	}
+	start, err := e.number(args, 1, ctx)
+	if err != "" {
+		return err
+	}
+	n, err := e.number(args, 2, ctx)
+	if err != "" {
+		return err
+	}
+	if start < 1 || n < 0 {
+		return errValue
+	}
+	runes := []rune(s)
+	from, to := textSpan(runes, start, n)
+	return string(runes[from:to])
+}
+
+func fnConcatenate(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+	var sb strings.Builder
+	for i := range args {
+		s, err := e.text(args, i, ctx)
+		if err != "" {
+			return err
+		}
+		sb.WriteString(s)
+	}
+	return sb.String()
+}
+
+func fnRept(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+	s, err := e.text(args, 0, ctx)
+	if err != "" {
+		return err
+	}
+	n, err := e.number(args, 1, ctx)
+	if err != "" {
+		return err
+	}
+	if n < 0 || float64(len(s))*n > 32767 {
+		return errValue
+	}
+	return strings.Repeat(s, int(min(n, 32767)))
+}
+
+func fnExact(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+	a, err := e.text(args, 0, ctx)
+	if err != "" {
+		return err
+	}
+	b, err := e.text(args, 1, ctx)
+	if err != "" {
+		return err
+	}
+	return a == b
+}
+
+// fnFind implements FIND, or SEARCH when search is set: case-insensitive and with wildcards.
+func fnFind(search bool) builtin {
+	return func(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+		find, err := e.text(args, 0, ctx)
+		if err != "" {
+			return err
+		}
+		within, err := e.text(args, 1, ctx)
+		if err != "" {
+			return err
+		}
+		start := 1.0
+		if !isMissing(args, 2) {
+			if start, err = e.number(args, 2, ctx); err != "" {
+				return err
+			}
+		}
+		runes := []rune(within)
+		if start < 1 || start > float64(len(runes)+1) {
+			return errValue
+		}
+		rest := string(runes[int(start)-1:])
+
+		index := -1
+		if search {
+			re, err := regexp.Compile("(?is)" + wildcardPattern(find))
+			if err == nil {
+				if loc := re.FindStringIndex(rest); loc != nil {
+					index = loc[0]
+				}
+			}
+		} else {
+			index = strings.Index(rest, find)
+		}
+		if index < 0 {
+			return errValue
+		}
+		return float64(int(start) + utf8.RuneCountInString(rest[:index]))
+	}
+}
+
+func fnSubstitute(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+	var s [3]string
+	for i := range s {
+		var err formulaError
+		if s[i], err = e.text(args, i, ctx); err != "" {
+			return err
+		}
+	}
+	text, old, replacement := s[0], s[1], s[2]
+	if old == "" {
+		return text
+	}
+	if isMissing(args, 3) {
+		return strings.ReplaceAll(text, old, replacement)
+	}
+
+	instance, err := e.number(args, 3, ctx)
+	if err != "" {
+		return err
+	}
+	if instance < 1 {
+		return errValue
+	}
+	// replace the n-th occurrence only
+	pos := 0
+	for n := 1; ; n++ {
+		i := strings.Index(text[pos:], old)
+		if i < 0 {
+			return text
+		}
+		if n == int(instance) {
+			return text[:pos+i] + replacement + text[pos+i+len(old):]
+		}
+		pos += i + len(old)
+	}
+}
+
+func fnReplace(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+	s, err := e.text(args, 0, ctx)
+	if err != "" {
+		return err
+	}
+	start, err := e.number(args, 1, ctx)
+	if err != "" {
+		return err
+	}
+	n, err := e.number(args, 2, ctx)
+	if err != "" {
+		return err
+	}
+	replacement, err := e.text(args, 3, ctx)
+	if err != "" {
+		return err
+	}
+	if start < 1 || n < 0 {
+		return errValue
+	}
+	runes := []rune(s)
+	from, to := textSpan(runes, start, n)
+	return string(runes[:from]) + replacement + string(runes[to:])
+}
+
+// textSpan returns the bounds of n characters from the 1-based start, cut at the end of runes. The numbers
+// are clamped before the conversion to int, which would overflow for huge values.
+func textSpan(runes []rune, start, n float64) (from, to int) {
+	from = int(min(start-1, float64(len(runes))))
+	to = from + int(min(n, float64(len(runes)-from)))
+	return from, to
+}
+
+func fnValue(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+	value := e.scalar(arg(args, 0), ctx)
+	switch v := value.(type) {
+	case float64, formulaError:
+		return v
+	case nil:
+		return float64(0)
+	case string:
+		if n, ok := parseNumber(v); ok {
+			return n
+		}
+	}
+	return errValue
+}
+
+func fnT(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+	switch v := e.scalar(arg(args, 0), ctx).(type) {
+	case string, formulaError:
+		return v
+	}
+	return ""
+}
+
+func fnN(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+	switch v := e.scalar(arg(args, 0), ctx).(type) {
+	case float64, formulaError:
+		return v
+	case bool:
+		if v {
+			return float64(1)
+		}
+	}
+	return float64(0)
+}
+
+func fnChar(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+	n, err := e.number(args, 0, ctx)
+	if err != "" {
+		return err
+	}
+	if n < 1 || n > 255 {
+		return errValue
+	}
+	return ConvertFrom(string([]byte{byte(n)}), DefaultCodePage)
+}
+
+func fnCode(e *Evaluator, ctx *evalContext, args []interface{}) interface{} {
+	s, err := e.text(args, 0, ctx)
+	if err != "" {
+		return err
+	}
+	if s == "" {
+		return errValue
+	}
+	r, _ := utf8.DecodeRuneInString(s)
+	return float64(r)
+}
+
+// criteria
+
+// newCriteria returns a test for the criteria of SUMIF and COUNTIF: a value to compare with,
+// or text such as ">=10", "<>done" or "a*" with a comparison operator and wildcards.
+func newCriteria(criteria interface{}) func(value interface{}) bool {
+	s, ok := criteria.(string)
+	if !ok {
+		return func(value interface{}) bool {
+			return value != nil && sameKind(value, criteria) && compareValues(value, criteria) == 0
+		}
+	}
+
+	op := ""
+	for _, prefix := range []string{"<=", ">=", "<>", "<", ">", "="} {
+		if strings.HasPrefix(s, prefix) {
+			op, s = prefix, s[len(prefix):]
+			break
+		}
+	}
+
+	var operand interface{} = s
+	if n, ok := parseNumber(s); ok {
+		operand = n
+	} else if b, err := toBool(s); err == "" && s != "" {
+		operand = b
+	}
+
+	if text, ok := operand.(string); ok && (op == "" || op == "=" || op == "<>") {
+		if text == "" {
+			// "=" matches empty cells, "<>" non-empty ones
+			return func(value interface{}) bool {
+				empty := value == nil || value == ""
+				return empty == (op != "<>")
+			}
+		}
+		re, err := regexp.Compile("(?is)^" + wildcardPattern(text) + "$")
+		if err != nil {
+			return func(interface{}) bool { return false }
+		}
+		return func(value interface{}) bool {
+			s, ok := value.(string)
+			return (ok && re.MatchString(s)) == (op != "<>")
+		}
+	}
+
+	return func(value interface{}) bool {
+		if value == nil || !sameKind(value, operand) {
+			return op == "<>"
+		}
+		c := compareValues(value, operand)
+		switch op {
+		case "<":
+			return c < 0
+		case "<=":
+			return c <= 0
+		case ">":
+			return c > 0
+		case ">=":
+			return c >= 0
+		case "<>":
+			return c != 0
+		}
+		return c == 0
+	}
+}
+
+// wildcardPattern converts Excel wildcards (* any text, ? one character, ~ escape) into a regular expression.
+func wildcardPattern(s string) string {
+	var sb strings.Builder
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case r == '~' && i+1 < len(runes):
+			i++
+			sb.WriteString(regexp.QuoteMeta(string(runes[i])))
+		case r == '*':
+			sb.WriteString(".*")
+		case r == '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return sb.String()
+}
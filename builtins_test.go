@@ -0,0 +1,177 @@
+package xls
+
+import (
+	"errors"
+	"testing"
+)
+
+// evalSheet returns a workbook and its first sheet, with the cells A1=2, A2=3, B1="two", B2="three" and Sheet2!A1=7,
+// followed by the records.
+func evalSheet(t *testing.T, records ...record) (*XLS, *Sheet) {
+	t.Helper()
+	data := []record{numberRec(0, 0, 2), numberRec(1, 0, 3), labelRec(0, 1, "two"), labelRec(1, 1, "three")}
+	book := formulaBook(append(data, records...))
+	book.sheets[1].records = []record{numberRec(0, 0, 7)}
+	xls := openTestBook(t, book)
+	return xls, xls.Sheets()[0]
+}
+
+func TestBuiltins(t *testing.T) {
+	cases := []struct {
+		name string
+		rgce []byte
+		want interface{}
+	}{
+		{"SUM", tokens(tArea(0, 1, 0, 0), tFuncVar(1, 4)), 5.0},
+		{"IF", tokens(tRef(0, 0), tInt(1), pack(uint8(ptgGT)), tStr("big"), tStr("small"), tFuncVar(3, 1)), "big"},
+		{"IF without else", tokens(tBool(false), tInt(1), tFuncVar(2, 1)), uint8(0)},
+		{"CHOOSE", tokens(tInt(2), tStr("a"), tStr("b"), tStr("c"), tFuncVar(4, 100)), "b"},
+		{"division by zero", tokens(tRef(0, 0), tInt(0), pack(uint8(ptgDiv))), "#DIV/0!"},
+		{"concatenation", tokens(tStr("a"), tInt(1), pack(uint8(ptgConcat))), "a1"},
+		{"3D reference", tokens(pack(uint8(ptgRef3d|0x20), uint16(0), uint16(0), uint16(0xC000)), tInt(10), pack(uint8(ptgMul))), 70.0},
+		{"VLOOKUP exact", tokens(tInt(3), tArea(0, 1, 0, 1), tInt(2), tBool(false), tFuncVar(4, 102)), "three"},
+		{"VLOOKUP approximate", tokens(tInt(10), tArea(0, 1, 0, 1), tInt(2), tFuncVar(3, 102)), "three"},
+		{"VLOOKUP not found", tokens(tInt(1), tArea(0, 1, 0, 1), tInt(2), tBool(false), tFuncVar(4, 102)), "#N/A"},
+		{"VLOOKUP empty column of the table", tokens(tInt(2), tArea(0, 0xFFFF, 0, 3), tInt(4), tBool(false), tFuncVar(4, 102)), 0.0},
+		{"VLOOKUP index past the table", tokens(tInt(2), tArea(0, 0xFFFF, 0, 3), tInt(5), tBool(false), tFuncVar(4, 102)), "#REF!"},
+		{"COUNTIF", tokens(tArea(0, 1, 0, 0), tStr(">2"), tFuncVar(2, 346)), 1.0},
+		{"DATE and DAY", tokens(tInt(2024), tInt(2), tInt(29), tFunc(65), tFunc(67)), 29.0},
+		{"DATE before 1900", tokens(tInt(1), tInt(1), tInt(1), tFunc(65)), 367.0},
+		{"DATE rolling over", tokens(tInt(2023), tInt(14), tInt(1), tFunc(65)), 45323.0},
+		{"PROPER", tokens(tStr("hello world"), tFunc(114)), "Hello World"},
+		{"LEN", tokens(tStr("héllo"), tFunc(32)), 5.0},
+		{"ROUND", tokens(pack(uint8(ptgNum), 2.345), tInt(2), tFunc(27)), 2.35},
+	}
+
+	var records []record
+	for i, c := range cases {
+		records = append(records, formulaRec(i, 5, 0, c.rgce))
+	}
+	xls, sheet := evalSheet(t, records...)
+	e := NewEvaluator(xls)
+	for i, c := range cases {
+		value, _, err := e.Evaluate(sheet, i, 5)
+		if err != nil || value != c.want {
+			t.Errorf("%s: got %#v, %v, want %#v", c.name, value, err, c.want)
+		}
+	}
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	xls, sheet := evalSheet(t,
+		formulaRec(0, 5, 0, tokens(tRef(0, 5), tInt(1), pack(uint8(ptgAdd)))),         // F1: F1+1
+		formulaRec(1, 5, 0, tokens(tBool(true), tInt(5), tRef(0, 5), tFuncVar(3, 1))), // F2: IF(TRUE,5,F1)
+		formulaRec(2, 5, 0, tokens(tInt(2), tInt(5), tRef(0, 5), tFuncVar(3, 100))),   // F3: CHOOSE(2,5,F1)
+		formulaRec(3, 5, 0, tokens(tRef(2, 5), tInt(1), pack(uint8(ptgAdd)))),         // F4: F3+1
+		formulaRec(4, 5, 42, tokens(tInt(7), tFuncVar(1, 354))),                       // F5: ROMAN(7)
+		formulaRec(5, 5, 43, tokens(pack(uint8(ptgNameX|0x20), uint16(3), uint16(1), uint16(0)), tRef(0, 0),
+			tFuncVar(2, functionUserDefined))), // F6: EOMONTH(A1)
+		formulaRec(6, 5, 44, tokens(tRef(4, 5), tInt(1), pack(uint8(ptgAdd)))), // F7: F5+1
+	)
+	e := NewEvaluator(xls)
+
+	if value, _, err := e.Evaluate(sheet, 1, 5); err != nil || value != 5.0 {
+		t.Errorf("IF with a circular reference in the branch not taken: got %#v, %v", value, err)
+	}
+	for _, row := range []int{0, 2, 3} {
+		if _, _, err := e.Evaluate(sheet, row, 5); !errors.Is(err, ErrCircularReference) {
+			t.Errorf("F%d: got %v, want ErrCircularReference", row+1, err)
+		}
+	}
+	for _, row := range []int{4, 5, 6} {
+		if _, _, err := e.Evaluate(sheet, row, 5); !errors.Is(err, ErrUnsupportedFormula) {
+			t.Errorf("F%d: got %v, want ErrUnsupportedFormula", row+1, err)
+		}
+	}
+}
+
+func TestRecalculate(t *testing.T) {
+	xls, sheet := evalSheet(t,
+		formulaRec(0, 5, 0, tokens(tArea(0, 1, 0, 0), tFuncVar(1, 4))),        // F1: SUM(A1:A2)
+		formulaRec(1, 5, 0, tokens(tRef(1, 5), tInt(1), pack(uint8(ptgAdd)))), // F2: F2+1
+		formulaRec(2, 5, 42, tokens(tInt(7), tFuncVar(1, 354))),               // F3: ROMAN(7)
+	)
+
+	if err := xls.Recalculate(); !errors.Is(err, ErrCircularReference) {
+		t.Errorf("got %v, want ErrCircularReference", err)
+	}
+	for row, want := range []float64{5, 0, 42} {
+		if value := sheet.Row(row).Cell(5).Value(); value != want {
+			t.Errorf("F%d: got %#v, want %v", row+1, value, want)
+		}
+	}
+}
+
+func TestDate1904(t *testing.T) {
+	book := &testBook{
+		globals: []record{rec(XLS_TYPE_DATEMODE, uint16(1))},
+		sheets: []testSheet{{name: "S", records: []record{
+			formulaRec(0, 0, 0, tokens(tInt(5), tInt(1), tInt(1), tFunc(65))),    // DATE(5,1,1), 1905-01-01
+			formulaRec(1, 0, 0, tokens(tInt(1902), tInt(1), tInt(1), tFunc(65))), // before 1904
+		}}},
+	}
+	xls := openTestBook(t, book)
+	sheet := xls.Sheets()[0]
+	e := NewEvaluator(xls)
+	for row, want := range []interface{}{366.0, "#NUM!"} {
+		if value, _, err := e.Evaluate(sheet, row, 0); err != nil || value != want {
+			t.Errorf("A%d: got %#v, %v, want %#v", row+1, value, err, want)
+		}
+	}
+}
+
+func TestBuiltinBounds(t *testing.T) {
+	huge := pack(uint8(ptgNum), 1e300)
+	array := pack(uint8(ptgArray|0x40), make([]byte, 7))
+	square := pack(uint8(1), uint16(1), uint8(1), 1.0, uint8(1), 2.0, uint8(1), 3.0, uint8(1), 4.0) // {1,2;3,4}
+	row := pack(uint8(1), uint16(0), uint8(1), 1.0, uint8(1), 2.0)                                  // {1,2}
+	cases := []struct {
+		name        string
+		rgce, extra []byte
+		want        interface{}
+	}{
+		{"INDEX", tokens(array, tInt(2), tInt(2), tFuncVar(3, 29)), square, 4.0},
+		{"INDEX column past the array", tokens(array, tInt(0), tInt(5), tFuncVar(3, 29)), square, "#REF!"},
+		{"INDEX row past the array", tokens(array, tInt(5), tInt(0), tFuncVar(3, 29)), square, "#REF!"},
+		{"INDEX huge row of an array", tokens(array, huge, tInt(1), tFuncVar(3, 29)), square, "#REF!"},
+		{"INDEX huge row of a reference", tokens(tArea(0, 1, 0, 1), huge, tFuncVar(2, 29)), nil, "#REF!"},
+		{"INDEX row vector", tokens(array, tInt(2), tFuncVar(2, 29)), row, 2.0},
+		{"LEFT", tokens(tStr("abc"), huge, tFuncVar(2, 115)), nil, "abc"},
+		{"RIGHT", tokens(tStr("abc"), huge, tFuncVar(2, 116)), nil, "abc"},
+		{"MID huge start", tokens(tStr("abc"), huge, tInt(2), tFunc(31)), nil, ""},
+		{"MID huge count", tokens(tStr("abc"), tInt(2), huge, tFunc(31)), nil, "bc"},
+		{"REPLACE huge count", tokens(tStr("abc"), tInt(2), huge, tStr("X"), tFunc(119)), nil, "aX"},
+		{"REPLACE huge start", tokens(tStr("abc"), huge, tInt(1), tStr("X"), tFunc(119)), nil, "abcX"},
+		{"REPT", tokens(tStr("ab"), huge, tFunc(30)), nil, "#VALUE!"},
+		{"REPT empty text", tokens(tStr(""), huge, tFunc(30)), nil, ""},
+		{"VLOOKUP", tokens(tInt(2), tArea(0, 1, 0, 1), huge, tBool(false), tFuncVar(4, 102)), nil, "#REF!"},
+		{"FIND", tokens(tStr("a"), tStr("abc"), huge, tFuncVar(3, 124)), nil, "#VALUE!"},
+	}
+
+	var records []record
+	for i, c := range cases {
+		records = append(records, rec(XLS_TYPE_FORMULA, i, 5, 15, 0.0, uint16(0), uint32(0), uint16(len(c.rgce)), c.rgce, c.extra))
+	}
+	xls, sheet := evalSheet(t, records...)
+	e := NewEvaluator(xls)
+	for i, c := range cases {
+		value, _, err := e.Evaluate(sheet, i, 5)
+		if err != nil || value != c.want {
+			t.Errorf("%s: got %#v, %v, want %#v", c.name, value, err, c.want)
+		}
+	}
+}
+
+func TestRecalculateOrder(t *testing.T) {
+	// circular references in F1, C1 and A3: the first one in row and column order is reported
+	selfRef := func(row, col int) record {
+		return formulaRec(row, col, 0, tokens(tRef(row, col), tInt(1), pack(uint8(ptgAdd))))
+	}
+	for i := 0; i < 20; i++ {
+		book := &testBook{sheets: []testSheet{{name: "S", records: []record{selfRef(0, 5), selfRef(0, 2), selfRef(2, 0)}}}}
+		err := openTestBook(t, book).Recalculate()
+		if !errors.Is(err, ErrCircularReference) || err.Error() != "circular reference: S!C1" {
+			t.Fatalf("got %v", err)
+		}
+	}
+}
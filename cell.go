@@ -1,5 +1,7 @@
 package xls
 
+import "time"
+
 type CellDataType string
 
 const (
@@ -9,13 +11,38 @@ const (
 	CellDataTypeError   CellDataType = "e"
 	//CellDataTypeString2 CellDataType = "str"
 	//CellDataTypeFormula CellDataType = "f"
-	//CellDataTypeNull    CellDataType = "null"
+	CellDataTypeNull CellDataType = "null"
 	//CellDataTypeInline  CellDataType = "inlineStr"
 )
 
 type Cell struct {
 	value    interface{}
 	dataType CellDataType
+
+	// workbook the cell belongs to
+	xls *XLS
+
+	// xfIndex is the index of the XF record with the formatting of the cell
+	xfIndex int
+
+	// shared is the rich text string of the shared string table the value comes from
+	shared *sstString
+
+	// formula holds the parsed expression of formula cells
+	formula []ptg
+
+	// arrayRange is the range of the array formula the cell belongs to
+	arrayRange *Range
+
+	// mergeRange is the merged area the cell belongs to, mergeAnchor is set for its top-left cell
+	mergeRange  *Range
+	mergeAnchor bool
+
+	// hyperlink is the link of the cell, from the hyperlinks of its sheet
+	hyperlink *Hyperlink
+
+	// comment is the comment of the cell, from the comments of its sheet
+	comment *Comment
 }
 
 func (c *Cell) Value() interface{} {
@@ -26,6 +53,146 @@ func (c *Cell) DataType() CellDataType {
 	return c.dataType
 }
 
+// XFIndex returns the index of the cell format (XF record) of the cell. Cells stored by BLANK
+// and MULBLANK records have the type CellDataTypeNull, a nil value and only carry this index.
+func (c *Cell) XFIndex() int {
+	return c.xfIndex
+}
+
+// NumberFormat returns the number format code of the cell, e.g. "0.00%" or "d-mmm-yy".
+func (c *Cell) NumberFormat() string {
+	if c.xls == nil {
+		return "General"
+	}
+	return c.xls.numberFormat(c.xfIndex)
+}
+
+// Font returns the font of the cell, or nil if the cell has no valid cell format.
+func (c *Cell) Font() *Font {
+	style := c.Style()
+	if style == nil {
+		return nil
+	}
+	return style.Font
+}
+
+// Style returns the formatting of the cell, or nil if the cell has no valid cell format.
+func (c *Cell) Style() *Style {
+	if c.xls == nil {
+		return nil
+	}
+	return c.xls.style(c.xfIndex)
+}
+
+// RichText returns the formatting runs of a string cell with characters in different fonts,
+// or nil if the whole text uses the font of the cell.
+func (c *Cell) RichText() []TextRun {
+	if c.shared == nil || c.shared.runs == nil {
+		return nil
+	}
+	return c.shared.textRuns(c.xls, c.Font())
+}
+
+// Phonetic returns the phonetic reading of the text of the cell, or nil if it has none.
+func (c *Cell) Phonetic() *Phonetic {
+	if c.shared == nil || c.shared.phonetic == nil {
+		return nil
+	}
+
+	p := c.shared.phonetic
+	return &Phonetic{
+		Text:      p.text,
+		Type:      p.typ,
+		Alignment: p.alignment,
+		Font:      c.xls.font(p.fontIndex),
+		Runs:      append([]PhoneticRun(nil), p.runs...),
+	}
+}
+
+// Time returns the value of a numeric cell formatted as date or time, converted from the date serial
+// of the 1900 or 1904 date system of the workbook. Dates are in UTC, as the file does not store a time zone.
+// It reports false for other cells.
+func (c *Cell) Time() (time.Time, bool) {
+	serial, ok := c.value.(float64)
+	if !ok || serial < 0 || !isDateFormat(c.NumberFormat()) {
+		return time.Time{}, false
+	}
+	return excelTime(serial, c.xls.date1904), true
+}
+
+// FormattedValue returns the value of the cell as displayed by Excel with the number format of the cell,
+// e.g. "12.5%", "$1,234.00" or "3/14/2024". Colors are not applied and column widths are not taken
+// into account; empty cells return an empty string.
+func (c *Cell) FormattedValue() string {
+	switch c.dataType {
+	case CellDataTypeNumeric:
+		value, _ := c.value.(float64)
+		return formatNumber(value, c.NumberFormat(), c.xls != nil && c.xls.date1904)
+	case CellDataTypeString:
+		value, _ := c.value.(string)
+		return formatText(value, c.NumberFormat())
+	case CellDataTypeBool:
+		if value, _ := c.value.(uint8); value != 0 {
+			return "TRUE"
+		}
+		return "FALSE"
+	case CellDataTypeError:
+		value, _ := c.value.(string)
+		return value
+	}
+	return ""
+}
+
+// Formula returns the formula of the cell in A1 notation, e.g. "=SUM(B2:B10)*Sheet2!C3".
+// It returns an empty string for constant cells and for formulas that cannot be decoded.
+func (c *Cell) Formula() string {
+	if c.formula == nil {
+		return ""
+	}
+
+	text, err := decompileFormula(c.formula)
+	if err != nil {
+		return ""
+	}
+
+	return "=" + text
+}
+
+// ArrayRange returns the range spanned by the array formula the cell belongs to.
+// It reports false for cells that are not part of an array formula.
+func (c *Cell) ArrayRange() (Range, bool) {
+	if c.arrayRange == nil {
+		return Range{}, false
+	}
+	return *c.arrayRange, true
+}
+
+// MergeRange returns the merged area the cell belongs to. anchor tells whether the cell is the top-left cell
+// of the area, which holds its value, or a covered cell. It reports false for cells that are not merged.
+func (c *Cell) MergeRange() (rng Range, anchor bool, ok bool) {
+	if c.mergeRange == nil {
+		return Range{}, false, false
+	}
+	return *c.mergeRange, c.mergeAnchor, true
+}
+
+// Hyperlink returns the hyperlink of the cell. It reports false for cells without a link.
+func (c *Cell) Hyperlink() (Hyperlink, bool) {
+	if c.hyperlink == nil {
+		return Hyperlink{}, false
+	}
+	return *c.hyperlink, true
+}
+
+// Comment returns the comment of the cell with its author, text and visibility.
+// It reports false for cells without a comment.
+func (c *Cell) Comment() (Comment, bool) {
+	if c.comment == nil {
+		return Comment{}, false
+	}
+	return *c.comment, true
+}
+
 func (c *Cell) setValue(value interface{}, dataType CellDataType) {
 	c.value = value
 	c.dataType = dataType
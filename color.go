@@ -0,0 +1,215 @@
+package xls
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// ColorIndexRGB is the index of colors given as RGB value by XFEXT records
+	ColorIndexRGB = -1
+	// ColorIndexTheme is the index of theme colors of XFEXT records
+	ColorIndexTheme = -2
+)
+
+// Color is a color of the workbook, given by its index into the color palette,
+// or by an RGB value or theme color in Excel 2007 and later.
+type Color struct {
+	// Index is the palette index: 0-63 palette colors, 0x40 system window text, 0x41 system window
+	// background, 0x7FFF automatic font color; or ColorIndexRGB and ColorIndexTheme.
+	Index int
+
+	// Theme is the theme color for ColorIndexTheme: 0 light 1, 1 dark 1, 2 light 2, 3 dark 2,
+	// 4-9 accent 1-6, 10 hyperlink, 11 followed hyperlink.
+	Theme int
+	// Tint lightens (positive) or darkens (negative) the color, from -1 to 1. It is applied to R, G and B.
+	Tint float64
+
+	R, G, B uint8
+}
+
+// Hex returns the color as RRGGBB, e.g. "FF0000".
+func (c Color) Hex() string {
+	return fmt.Sprintf("%02X%02X%02X", c.R, c.G, c.B)
+}
+
+// builtInColors are the colors with fixed indexes 0-7 and the system colors.
+var builtInColors = map[int][3]uint8{
+	0x00:   {0x00, 0x00, 0x00},
+	0x01:   {0xFF, 0xFF, 0xFF},
+	0x02:   {0xFF, 0x00, 0x00},
+	0x03:   {0x00, 0xFF, 0x00},
+	0x04:   {0x00, 0x00, 0xFF},
+	0x05:   {0xFF, 0xFF, 0x00},
+	0x06:   {0xFF, 0x00, 0xFF},
+	0x07:   {0x00, 0xFF, 0xFF},
+	0x40:   {0x00, 0x00, 0x00}, // system window text color
+	0x41:   {0xFF, 0xFF, 0xFF}, // system window background color
+	0x7FFF: {0x00, 0x00, 0x00}, // automatic font color
+}
+
+// defaultPalette is the BIFF8 color palette for indexes 8-63, changed by a PALETTE record.
+var defaultPalette = [56][3]uint8{
+	{0x00, 0x00, 0x00}, {0xFF, 0xFF, 0xFF}, {0xFF, 0x00, 0x00}, {0x00, 0xFF, 0x00},
+	{0x00, 0x00, 0xFF}, {0xFF, 0xFF, 0x00}, {0xFF, 0x00, 0xFF}, {0x00, 0xFF, 0xFF},
+	{0x80, 0x00, 0x00}, {0x00, 0x80, 0x00}, {0x00, 0x00, 0x80}, {0x80, 0x80, 0x00},
+	{0x80, 0x00, 0x80}, {0x00, 0x80, 0x80}, {0xC0, 0xC0, 0xC0}, {0x80, 0x80, 0x80},
+	{0x99, 0x99, 0xFF}, {0x99, 0x33, 0x66}, {0xFF, 0xFF, 0xCC}, {0xCC, 0xFF, 0xFF},
+	{0x66, 0x00, 0x66}, {0xFF, 0x80, 0x80}, {0x00, 0x66, 0xCC}, {0xCC, 0xCC, 0xFF},
+	{0x00, 0x00, 0x80}, {0xFF, 0x00, 0xFF}, {0xFF, 0xFF, 0x00}, {0x00, 0xFF, 0xFF},
+	{0x80, 0x00, 0x80}, {0x80, 0x00, 0x00}, {0x00, 0x80, 0x80}, {0x00, 0x00, 0xFF},
+	{0x00, 0xCC, 0xFF}, {0xCC, 0xFF, 0xFF}, {0xCC, 0xFF, 0xCC}, {0xFF, 0xFF, 0x99},
+	{0x99, 0xCC, 0xFF}, {0xFF, 0x99, 0xCC}, {0xCC, 0x99, 0xFF}, {0xFF, 0xCC, 0x99},
+	{0x33, 0x66, 0xFF}, {0x33, 0xCC, 0xCC}, {0x99, 0xCC, 0x00}, {0xFF, 0xCC, 0x00},
+	{0xFF, 0x99, 0x00}, {0xFF, 0x66, 0x00}, {0x66, 0x66, 0x99}, {0x96, 0x96, 0x96},
+	{0x00, 0x33, 0x66}, {0x33, 0x99, 0x66}, {0x00, 0x33, 0x00}, {0x33, 0x33, 0x00},
+	{0x99, 0x33, 0x00}, {0x99, 0x33, 0x66}, {0x33, 0x33, 0x99}, {0x33, 0x33, 0x33},
+}
+
+// color returns the color with a palette index, using the palette of the workbook for indexes 8-63.
+// Unknown indexes are black.
+func (xls *XLS) color(index int) Color {
+	rgb, ok := builtInColors[index]
+	if !ok && index >= 8 && index < 64 {
+		rgb = defaultPalette[index-8]
+		if index-8 < len(xls.palette) {
+			rgb = xls.palette[index-8]
+		}
+	}
+	return Color{Index: index, R: rgb[0], G: rgb[1], B: rgb[2]}
+}
+
+// themeColors are the colors of the default Office theme. The THEME record of the workbook is not read.
+var themeColors = [12][3]uint8{
+	{0xFF, 0xFF, 0xFF}, {0x00, 0x00, 0x00}, {0xEE, 0xEC, 0xE1}, {0x1F, 0x49, 0x7D},
+	{0x4F, 0x81, 0xBD}, {0xC0, 0x50, 0x4D}, {0x9B, 0xBB, 0x59}, {0x80, 0x64, 0xA2},
+	{0x4B, 0xAC, 0xC6}, {0xF7, 0x96, 0x46}, {0x00, 0x00, 0xFF}, {0x80, 0x00, 0x80},
+}
+
+// resolve returns the color with its RGB value taken from the palette and the tint of XFEXT colors applied.
+// The palette is only known once the globals are read, so colors are resolved when a style is built.
+func (xls *XLS) resolve(c Color) Color {
+	tint := c.Tint
+	if c.Index >= 0 {
+		c = xls.color(c.Index)
+	}
+	return c.withTint(tint)
+}
+
+// readFullColor reads a FullColorExt structure of an XFEXT record. It reports false for
+// automatic colors and colors that are not set. The RGB value of palette colors and the tint are applied by resolve.
+func readFullColor(data []byte) (Color, bool) {
+	if len(data) < 8 {
+		return Color{}, false
+	}
+
+	// offset: 0; size: 2; color type
+	xclrType := getUInt2d(data, 0)
+	// offset: 2; size: 2; tint and shade, -32767 to 32767
+	tint := float64(int16(getUInt2d(data, 2))) / 32767
+	// offset: 4; size: 4; color value
+	value := data[4:8]
+
+	var c Color
+	switch xclrType {
+	case 0x0001: // palette index
+		c = Color{Index: int(getUInt2d(value, 0))}
+	case 0x0002: // RGB, followed by an unused byte
+		c = Color{Index: ColorIndexRGB, R: value[0], G: value[1], B: value[2]}
+	case 0x0003: // theme color
+		theme := int(getInt4d(value, 0))
+		if theme < 0 || theme >= len(themeColors) {
+			return Color{}, false
+		}
+		rgb := themeColors[theme]
+		c = Color{Index: ColorIndexTheme, Theme: theme, R: rgb[0], G: rgb[1], B: rgb[2]}
+	default: // automatic or not set
+		return Color{}, false
+	}
+
+	c.Tint = tint
+	return c, true
+}
+
+// withTint returns the color lightened or darkened by tint, changing its luminance like Excel.
+func (c Color) withTint(tint float64) Color {
+	if tint == 0 {
+		return c
+	}
+
+	h, s, l := rgbToHsl(c.R, c.G, c.B)
+	if tint < 0 {
+		l = l * (1 + tint)
+	} else {
+		l = l*(1-tint) + tint
+	}
+	c.R, c.G, c.B = hslToRgb(h, s, l)
+	c.Tint = tint
+	return c
+}
+
+func rgbToHsl(r, g, b uint8) (h, s, l float64) {
+	rf, gf, bf := float64(r)/255, float64(g)/255, float64(b)/255
+	maxC := math.Max(rf, math.Max(gf, bf))
+	minC := math.Min(rf, math.Min(gf, bf))
+	l = (maxC + minC) / 2
+	if maxC == minC {
+		return 0, 0, l
+	}
+
+	d := maxC - minC
+	if l > 0.5 {
+		s = d / (2 - maxC - minC)
+	} else {
+		s = d / (maxC + minC)
+	}
+	switch maxC {
+	case rf:
+		h = (gf - bf) / d
+		if gf < bf {
+			h += 6
+		}
+	case gf:
+		h = (bf-rf)/d + 2
+	default:
+		h = (rf-gf)/d + 4
+	}
+	return h / 6, s, l
+}
+
+func hslToRgb(h, s, l float64) (uint8, uint8, uint8) {
+	if s == 0 {
+		v := uint8(math.Round(l * 255))
+		return v, v, v
+	}
+
+	var q float64
+	if l < 0.5 {
+		q = l * (1 + s)
+	} else {
+		q = l + s - l*s
+	}
+	p := 2*l - q
+
+	hue := func(t float64) uint8 {
+		if t < 0 {
+			t++
+		}
+		if t > 1 {
+			t--
+		}
+		var v float64
+		switch {
+		case t < 1.0/6:
+			v = p + (q-p)*6*t
+		case t < 1.0/2:
+			v = q
+		case t < 2.0/3:
+			v = p + (q-p)*(2.0/3-t)*6
+		default:
+			v = p
+		}
+		return uint8(math.Round(v * 255))
+	}
+	return hue(h + 1.0/3), hue(h), hue(h - 1.0/3)
+}
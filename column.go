@@ -0,0 +1,63 @@
+package xls
+
+// maxDigitWidth is the width in pixels of the widest digit of the default font (Arial 10, Calibri 11)
+// at 96 DPI, the unit of column widths.
+const maxDigitWidth = 7
+
+// Column is the layout of a column from its COLINFO record, or the default column of the sheet.
+type Column struct {
+	// Width is the width in characters as shown by Excel, Pixels the width in pixels at 96 DPI
+	Width  float64
+	Pixels int
+
+	Hidden bool
+	// OutlineLevel is the outline (grouping) level from 0 to 7, Collapsed is set for the column
+	// following a collapsed group
+	OutlineLevel int
+	Collapsed    bool
+
+	// XFIndex is the index of the XF record used for the empty cells of the column
+	XFIndex int
+
+	// Default is set for columns without a COLINFO record, which have the default width of the sheet
+	Default bool
+}
+
+// colInfo is a COLINFO record, the layout of a block of columns.
+type colInfo struct {
+	firstCol, lastCol int
+	column            Column
+}
+
+// Column returns the layout of the column with the zero-based index col. Columns without a COLINFO record
+// have the width of the DEFCOLWIDTH or STANDARDWIDTH record.
+func (s *Sheet) Column(col int) Column {
+	for _, info := range s.colInfos {
+		if col >= info.firstCol && col <= info.lastCol {
+			return info.column
+		}
+	}
+
+	column := Column{XFIndex: 15, Default: true}
+	if s.standardWidth >= 0 {
+		column.Pixels = columnPixels(s.standardWidth)
+	} else {
+		// the default width in characters plus 5 pixels padding, rounded up to a multiple of 8 pixels
+		column.Pixels = (s.defColWidth*maxDigitWidth + 5 + 7) / 8 * 8
+	}
+	column.Width = columnWidth(column.Pixels)
+	return column
+}
+
+// columnPixels converts a width in 1/256 of the digit width, including padding, to pixels.
+func columnPixels(width int) int {
+	return (width + 128/maxDigitWidth) * maxDigitWidth / 256
+}
+
+// columnWidth converts a width in pixels to characters as shown by Excel, rounded to 2 decimals.
+func columnWidth(pixels int) float64 {
+	if pixels <= 5 {
+		return 0
+	}
+	return float64(int(float64(pixels-5)/maxDigitWidth*100+0.5)) / 100
+}
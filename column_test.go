@@ -0,0 +1,39 @@
+package xls
+
+import "testing"
+
+func colInfoRec(firstCol, lastCol, width, xfIndex, options int) record {
+	return rec(XLS_TYPE_COLINFO, firstCol, lastCol, width, xfIndex, options, 0)
+}
+
+func TestColumns(t *testing.T) {
+	book := &testBook{sheets: []testSheet{
+		{name: "Default", records: []record{numberRec(0, 0, 1)}},
+		{name: "Standard", records: []record{
+			rec(XLS_TYPE_DEFCOLWIDTH, uint16(20)),
+			rec(XLS_TYPE_STANDARDWIDTH, uint16(10*256)),
+			// 16 characters wide, hidden and grouped
+			colInfoRec(2, 4, 4352, 20, 0x0001|0x0100),
+			colInfoRec(5, 5, 2560, 15, 0x1000),
+			numberRec(0, 0, 1),
+		}},
+		{name: "DefColWidth", records: []record{rec(XLS_TYPE_DEFCOLWIDTH, uint16(12))}},
+	}}
+	sheets := openTestBook(t, book).Sheets()
+
+	cases := []struct {
+		sheet, col int
+		want       Column
+	}{
+		{0, 0, Column{Width: 8.43, Pixels: 64, XFIndex: 15, Default: true}},
+		{1, 0, Column{Width: 9.29, Pixels: 70, XFIndex: 15, Default: true}},
+		{1, 3, Column{Width: 16.29, Pixels: 119, XFIndex: 20, Hidden: true, OutlineLevel: 1}},
+		{1, 5, Column{Width: 9.29, Pixels: 70, XFIndex: 15, Collapsed: true}},
+		{2, 7, Column{Width: 13, Pixels: 96, XFIndex: 15, Default: true}},
+	}
+	for _, c := range cases {
+		if got := sheets[c.sheet].Column(c.col); got != c.want {
+			t.Errorf("sheet %d column %d: got %+v, want %+v", c.sheet, c.col, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package xls
+
+// Comment is the note of a cell, from a NOTE record and the OBJ and TXO records of its text box.
+type Comment struct {
+	Row int
+	Col int
+
+	Author string
+	Text   string
+	// Runs is the text split into its formatting runs, nil for BIFF7 comments which have no formatting
+	Runs []TextRun
+	// Visible is set for comments that are always shown, not only when the mouse is over the cell
+	Visible bool
+}
+
+// note is a NOTE record, linked to the text of its comment by the id of the OBJ record of the text box.
+type note struct {
+	row, col int
+	objID    int
+	author   string
+	visible  bool
+
+	// text of BIFF7 notes, which is stored in the NOTE records
+	text string
+}
+
+// textObject is the text of a TXO record with its formatting runs.
+type textObject struct {
+	text string
+	runs []formatRun
+}
+
+// resolveComments joins the NOTE records of the current sheet to the text of their TXO records
+// and links the cells to their comment.
+func (xls *XLS) resolveComments(sheet *Sheet) {
+	for _, n := range xls.notes {
+		comment := Comment{Row: n.row, Col: n.col, Author: n.author, Visible: n.visible, Text: n.text}
+		if txo, ok := xls.textObjects[n.objID]; ok {
+			comment.Text = txo.text
+			comment.Runs = xls.textRuns(txo.text, txo.runs, xls.font(0))
+		}
+		sheet.comments = append(sheet.comments, comment)
+	}
+
+	for i := range sheet.comments {
+		comment := &sheet.comments[i]
+		rng := Range{FirstRow: comment.Row, LastRow: comment.Row, FirstCol: comment.Col, LastCol: comment.Col}
+		sheet.eachCell(&rng, func(c *Cell) {
+			c.comment = comment
+		})
+	}
+
+	xls.notes = nil
+	xls.textObjects = nil
+}
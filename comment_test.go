@@ -0,0 +1,91 @@
+package xls
+
+import (
+	"strings"
+	"testing"
+)
+
+// objRec is an OBJ record of a comment with its ftCmo and ftEnd subrecords.
+func objRec(id int) record {
+	return rec(XLS_TYPE_OBJ, uint16(0x15), uint16(0x12), uint16(0x19), uint16(id), uint16(0x4011), make([]byte, 12),
+		uint16(0), uint16(0))
+}
+
+func txoRec(cch, cbRuns int) record {
+	return rec(XLS_TYPE_TXO, uint16(0x0212), uint16(0), make([]byte, 6), uint16(cch), uint16(cbRuns), uint16(0), make([]byte, 4))
+}
+
+func TestComments(t *testing.T) {
+	book := &testBook{sheets: []testSheet{{name: "S", records: []record{
+		labelRec(0, 0, "a"),
+		labelRec(2, 1, "b"),
+		// the text is split over two CONTINUE records, 8-bit and then 16-bit characters
+		objRec(1),
+		rec(XLS_TYPE_MSODRAWING, make([]byte, 8)),
+		txoRec(10, 24),
+		rec(XLS_TYPE_CONTINUE, uint8(0), "Bob: "),
+		rec(XLS_TYPE_CONTINUE, uint8(1), utf16Bytes("héllo")),
+		rec(XLS_TYPE_CONTINUE, uint16(0), uint16(0), uint32(0), uint16(5), uint16(1), uint32(0), uint16(10), uint16(0), uint32(0)),
+		objRec(2),
+		txoRec(3, 16),
+		rec(XLS_TYPE_CONTINUE, uint8(0), "Hi!"),
+		rec(XLS_TYPE_CONTINUE, uint16(0), uint16(0), uint32(0), uint16(3), uint16(0), uint32(0)),
+		rec(XLS_TYPE_NOTE, uint16(1), uint16(1), uint16(0x0002), uint16(1), xlString("Bob"), uint8(0)),
+		rec(XLS_TYPE_NOTE, uint16(0), uint16(0), uint16(0), uint16(2), wideString("Zoë"), uint8(0)),
+		// a comment without text object, outside the used range
+		rec(XLS_TYPE_NOTE, uint16(10), uint16(5), uint16(0), uint16(9), xlString("X"), uint8(0)),
+	}}}}
+	sheet := openTestBook(t, book).Sheets()[0]
+
+	comments := sheet.Comments()
+	if len(comments) != 3 {
+		t.Fatalf("got %d comments, want 3", len(comments))
+	}
+
+	c, ok := sheet.Row(1).Cell(1).Comment()
+	if !ok || c.Author != "Bob" || c.Text != "Bob: héllo" || !c.Visible || c.Row != 1 || c.Col != 1 {
+		t.Errorf("B2: got %+v", c)
+	}
+	if len(c.Runs) != 2 || c.Runs[0].Text != "Bob: " || c.Runs[1].Text != "héllo" {
+		t.Errorf("B2 runs: got %+v", c.Runs)
+	}
+
+	c, ok = sheet.Row(0).Cell(0).Comment()
+	if !ok || c.Author != "Zoë" || c.Text != "Hi!" || c.Visible {
+		t.Errorf("A1: got %+v", c)
+	}
+	if c := comments[2]; c.Row != 10 || c.Col != 5 || c.Text != "" {
+		t.Errorf("F11: got %+v", c)
+	}
+	if _, ok := sheet.Row(2).Cell(0).Comment(); ok {
+		t.Error("A3 has a comment")
+	}
+}
+
+func TestCommentsBIFF5(t *testing.T) {
+	// a note longer than 2048 characters continues in NOTE records with row 0xFFFF
+	text := strings.Repeat("0123456789", 210)
+	book := &testBook{biff5: true, sheets: []testSheet{{name: "S", records: []record{
+		labelRec5(0, 0, "a"),
+		rec(XLS_TYPE_NOTE, 0, 0, uint16(len(text)), text[:2048]),
+		rec(XLS_TYPE_NOTE, 0xFFFF, 0, uint16(len(text)-2048), text[2048:]),
+		rec(XLS_TYPE_NOTE, 3, 2, uint16(5), "short"),
+	}}}}
+	sheet := openTestBook(t, book).Sheets()[0]
+
+	comments := sheet.Comments()
+	if len(comments) != 2 {
+		t.Fatalf("got %d comments, want 2", len(comments))
+	}
+	if c := comments[0]; c.Row != 0 || c.Col != 0 || c.Text != text {
+		t.Errorf("A1: got %d characters, want %d", len(c.Text), len(text))
+	}
+	if c := comments[1]; c.Row != 3 || c.Col != 2 || c.Text != "short" {
+		t.Errorf("C4: got %+v", c)
+	}
+}
+
+// labelRec5 is a BIFF5 LABEL record, with an 8-bit string without option flags.
+func labelRec5(row, col int, s string) record {
+	return rec(XLS_TYPE_LABEL, row, col, 15, uint16(len(s)), s)
+}
@@ -32,13 +32,15 @@ func getInt2d(data []byte, pos int) int {
 */
 // Read 32-bit signed integer.
 func getInt4d(data []byte, pos int) int {
-	if pos < 0 {
+	if pos < 0 || pos >= len(data) {
 		return 0 //, errors.New(fmt.Sprintf("Parameter pos=%d is invalid.", pos))
 	}
 
 	if len(data) < pos+4 {
-		padding := make([]byte, pos+4-len(data))
-		data = append(data, padding...)
+		// pad a copy of the tail, never the caller's slice
+		padded := make([]byte, 4)
+		copy(padded, data[pos:])
+		data, pos = padded, 0
 	}
 
 	// FIX: represent numbers correctly on 64-bit system
@@ -100,6 +102,16 @@ func extractNumber(data []byte) float64 {
 	return value
 }
 
+// columnName returns the letters of a zero-based column index, e.g. 0 is A and 27 is AB.
+func columnName(col int) string {
+	name := ""
+	for col >= 0 {
+		name = string(rune('A'+col%26)) + name
+		col = col/26 - 1
+	}
+	return name
+}
+
 func equal(a, b []byte) bool {
 	if len(a) != len(b) {
 		return false
@@ -0,0 +1,58 @@
+package xls
+
+const (
+	ValidationAny        = 0x00
+	ValidationWhole      = 0x01
+	ValidationDecimal    = 0x02
+	ValidationList       = 0x03
+	ValidationDate       = 0x04
+	ValidationTime       = 0x05
+	ValidationTextLength = 0x06
+	ValidationCustom     = 0x07
+)
+
+const (
+	ValidationBetween            = 0x00
+	ValidationNotBetween         = 0x01
+	ValidationEqual              = 0x02
+	ValidationNotEqual           = 0x03
+	ValidationGreaterThan        = 0x04
+	ValidationLessThan           = 0x05
+	ValidationGreaterThanOrEqual = 0x06
+	ValidationLessThanOrEqual    = 0x07
+)
+
+const (
+	ValidationErrorStop        = 0x00
+	ValidationErrorWarning     = 0x01
+	ValidationErrorInformation = 0x02
+)
+
+// DataValidation is a data validation rule from a DATAVALIDATION record.
+type DataValidation struct {
+	// Ranges are the cells the rule applies to
+	Ranges []Range
+
+	Type     int // ValidationAny, ValidationWhole, ...
+	Operator int // ValidationBetween, ValidationNotBetween, ... not used by list and custom rules
+
+	// Formula1 and Formula2 are the operands of the condition as formula text without the leading '=',
+	// e.g. "10", "$A$1:$A$5" or `"Yes,No"`. Formula2 is empty unless the record holds a second formula,
+	// which Excel writes for ValidationBetween and ValidationNotBetween.
+	Formula1 string
+	Formula2 string
+	// List holds the items of an explicit list, nil for lists taken from cells
+	List []string
+
+	AllowBlank   bool
+	ShowDropDown bool
+
+	ShowInputMessage bool
+	PromptTitle      string
+	Prompt           string
+
+	ShowErrorMessage bool
+	ErrorStyle       int // ValidationErrorStop, ValidationErrorWarning or ValidationErrorInformation
+	ErrorTitle       string
+	Error            string
+}
@@ -0,0 +1,75 @@
+package xls
+
+import (
+	"reflect"
+	"testing"
+)
+
+// dataValidationRec is a DATAVALIDATION record; the texts are the prompt title, error title, prompt and error,
+// "\x00" for an empty one.
+func dataValidationRec(options uint32, texts [4]string, formula1, formula2 []byte, ranges ...Range) record {
+	parts := []interface{}{options}
+	for _, text := range texts {
+		parts = append(parts, xlString(text))
+	}
+	for _, rgce := range [][]byte{formula1, formula2} {
+		parts = append(parts, uint16(len(rgce)), uint16(0), rgce)
+	}
+	parts = append(parts, uint16(len(ranges)))
+	for _, rng := range ranges {
+		parts = append(parts, uint16(rng.FirstRow), uint16(rng.LastRow), uint16(rng.FirstCol), uint16(rng.LastCol))
+	}
+	return rec(XLS_TYPE_DATAVALIDATION, parts...)
+}
+
+func TestDataValidations(t *testing.T) {
+	book := &testBook{sheets: []testSheet{{name: "S", records: []record{
+		numberRec(0, 0, 1),
+		rec(XLS_TYPE_DATAVALIDATIONS, uint16(0), uint32(0), uint32(0), uint32(0xFFFFFFFF), uint32(3)),
+		// explicit list with a prompt and no error texts
+		dataValidationRec(ValidationList|0x80|0x100|0x40000|ValidationErrorWarning<<4,
+			[4]string{"Fruit", "\x00", "Pick one", "\x00"},
+			tStr("Apple\x00Banana\x00Pear"), nil,
+			Range{1, 9, 1, 1}, Range{0, 0, 3, 3}),
+		// the column to the left of the first range
+		dataValidationRec(ValidationCustom|0x80000,
+			[4]string{"\x00", "Invalid", "\x00", "Must be above the left cell"},
+			tokens(pack(uint8(ptgRefN|0x20), uint16(0), uint16(0xFF|0xC000)), tInt(0), pack(uint8(ptgGT))), nil,
+			Range{2, 5, 2, 2}, Range{9, 9, 4, 4}),
+		dataValidationRec(ValidationWhole|0x200|ValidationNotBetween<<20,
+			[4]string{"\x00", "\x00", "\x00", "\x00"},
+			tInt(1), tInt(10),
+			Range{0, 0, 5, 5}),
+	}}}}
+	got := openTestBook(t, book).Sheets()[0].DataValidations()
+
+	want := []DataValidation{
+		{
+			Ranges: []Range{{1, 9, 1, 1}, {0, 0, 3, 3}},
+			Type:   ValidationList, Operator: ValidationBetween,
+			Formula1: `"Apple,Banana,Pear"`, List: []string{"Apple", "Banana", "Pear"},
+			AllowBlank: true, ShowDropDown: true,
+			ShowInputMessage: true, PromptTitle: "Fruit", Prompt: "Pick one",
+			ErrorStyle: ValidationErrorWarning,
+		},
+		{
+			Ranges: []Range{{2, 5, 2, 2}, {9, 9, 4, 4}},
+			Type:   ValidationCustom, Operator: ValidationBetween,
+			Formula1: "B3>0", ShowDropDown: true,
+			ShowErrorMessage: true, ErrorTitle: "Invalid", Error: "Must be above the left cell",
+		},
+		{
+			Ranges: []Range{{0, 0, 5, 5}},
+			Type:   ValidationWhole, Operator: ValidationNotBetween,
+			Formula1: "1", Formula2: "10",
+		},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d rules, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("rule %d:\ngot  %+v\nwant %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package xls
+
+import (
+	"math"
+	"time"
+)
+
+var (
+	// day 0 of the 1900 date system; serials from 61 on count from here because Excel
+	// treats 1900 as a leap year and has a serial 60 for the non-existent 29 February 1900
+	epoch1900 = time.Date(1899, time.December, 30, 0, 0, 0, 0, time.UTC)
+	// day 0 of the 1904 date system used by workbooks created on old Macintosh versions
+	epoch1904 = time.Date(1904, time.January, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// excelTime converts an Excel date serial into a time in UTC. The fraction of the serial is the time of day,
+// rounded to the millisecond. date1904 selects the 1904 date system.
+func excelTime(serial float64, date1904 bool) time.Time {
+	epoch := epoch1900
+	if date1904 {
+		epoch = epoch1904
+	} else if serial < 61 {
+		// before the fake leap day the serials are off by one
+		epoch = epoch.AddDate(0, 0, 1)
+	}
+
+	days := math.Floor(serial)
+	ms := math.Round((serial - days) * 24 * 60 * 60 * 1000)
+
+	return epoch.AddDate(0, 0, int(days)).Add(time.Duration(ms) * time.Millisecond)
+}
+
+// excelSerial converts a time into an Excel date serial of the 1900 or 1904 date system.
+func excelSerial(t time.Time, date1904 bool) float64 {
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+
+	epoch := epoch1900
+	if date1904 {
+		epoch = epoch1904
+	}
+
+	serial := t.Sub(epoch).Hours() / 24
+	if !date1904 && serial < 61 {
+		serial--
+	}
+	return serial
+}
+
+// excelWeekday returns the day of the week Excel shows for a date serial, Sunday = 0. Excel counts
+// the weekdays before the fake 29 February 1900 from it, so they are one day earlier than the real ones.
+func excelWeekday(serial float64, date1904 bool) int {
+	weekday := int(excelTime(serial, date1904).Weekday())
+	if !date1904 && serial < 61 {
+		weekday = (weekday + 6) % 7
+	}
+	return weekday
+}
@@ -45,3 +45,25 @@ func CheckErrorCode(code ErrorCode) string {
 
 	return values[ErrCodesNull]
 }
+
+// mapErrorCode maps an error code stored in BOOLERR, FORMULA and formula tErr tokens to its text.
+func mapErrorCode(code byte) string {
+	switch code {
+	case 0x00:
+		return "#NULL!"
+	case 0x07:
+		return "#DIV/0!"
+	case 0x0F:
+		return "#VALUE!"
+	case 0x17:
+		return "#REF!"
+	case 0x1D:
+		return "#NAME?"
+	case 0x24:
+		return "#NUM!"
+	case 0x2A:
+		return "#N/A"
+	}
+
+	return CheckErrorCode(ErrCodesNull)
+}
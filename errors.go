@@ -0,0 +1,72 @@
+package xls
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrTruncated is reported when a structure extends past the end of its record, stream or file.
+	ErrTruncated = errors.New("data is truncated")
+
+	// ErrCorrupt is reported when a structure contains values that cannot be valid.
+	ErrCorrupt = errors.New("data is corrupt")
+
+	// ErrEncrypted is reported for password protected workbooks, which cannot be decrypted.
+	ErrEncrypted = errors.New("workbook is encrypted")
+
+	// ErrNoWorkbook is reported when the OLE container holds no Workbook or Book stream.
+	ErrNoWorkbook = errors.New("workbook stream not found")
+
+	// ErrCircularReference is reported by the evaluator when a formula depends on its own result.
+	ErrCircularReference = errors.New("circular reference")
+
+	// ErrUnsupportedFormula is reported by the evaluator for formulas using functions or tokens it cannot compute,
+	// e.g. add-in functions or data tables.
+	ErrUnsupportedFormula = errors.New("unsupported formula")
+)
+
+// RecordError describes a BIFF record that could not be read.
+type RecordError struct {
+	// Type is the record identifier, e.g. XLS_TYPE_SST.
+	Type uint16
+	// Offset is the position of the record header within the workbook stream.
+	Offset int
+	Err    error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("xls: record 0x%04x at offset %d: %v", e.Type, e.Offset, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// OLEError describes a failure while walking the sectors of the OLE container.
+type OLEError struct {
+	// Sector is the sector identifier (SecID) that was being followed, or -1 for the file header.
+	Sector int
+	Err    error
+}
+
+func (e *OLEError) Error() string {
+	if e.Sector < 0 {
+		return fmt.Sprintf("xls: ole header: %v", e.Err)
+	}
+	return fmt.Sprintf("xls: ole sector %d: %v", e.Sector, e.Err)
+}
+
+func (e *OLEError) Unwrap() error {
+	return e.Err
+}
+
+// newRecordError attaches the record type and stream offset to err,
+// unless a nested reader has already done so.
+func newRecordError(code uint16, offset int, err error) error {
+	var recErr *RecordError
+	if errors.As(err, &recErr) {
+		return err
+	}
+	return &RecordError{Type: code, Offset: offset, Err: err}
+}
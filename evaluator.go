@@ -0,0 +1,908 @@
+package xls
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// formulaError is an Excel error value, e.g. #DIV/0!, produced while evaluating a formula.
+type formulaError string
+
+const (
+	errNull  formulaError = "#NULL!"
+	errDiv0  formulaError = "#DIV/0!"
+	errValue formulaError = "#VALUE!"
+	errRef   formulaError = "#REF!"
+	errName  formulaError = "#NAME?"
+	errNum   formulaError = "#NUM!"
+	errNA    formulaError = "#N/A"
+)
+
+// missingArg is the value of an omitted function argument.
+type missingArg struct{}
+
+// reference is the evaluated form of reference tokens: one or more blocks of cells.
+type reference []sheetArea
+
+type sheetArea struct {
+	sheet *Sheet
+	rng   Range
+}
+
+// evalContext is the formula cell being evaluated.
+type evalContext struct {
+	sheet    *Sheet
+	row, col int
+
+	// array is set for cells of array formulas, whose operands evaluate to arrays
+	array bool
+}
+
+// Evaluator computes the values of formula cells from their parsed expressions.
+// It supports arithmetic, comparison and text operators, references across sheets, defined names
+// and a core set of built-in functions. IF and CHOOSE evaluate only the argument they select.
+// Results are cached, so an Evaluator reflects the cell values at the time they are first needed.
+type Evaluator struct {
+	xls *XLS
+
+	values map[*Cell]interface{}
+	failed map[*Cell]error
+	active map[*Cell]bool
+
+	activeNames map[int]bool
+
+	// err stops the evaluation of the current cell, see fail
+	err error
+}
+
+// NewEvaluator returns an evaluator for the formulas of the workbook.
+func NewEvaluator(xls *XLS) *Evaluator {
+	return &Evaluator{
+		xls:         xls,
+		values:      make(map[*Cell]interface{}),
+		failed:      make(map[*Cell]error),
+		active:      make(map[*Cell]bool),
+		activeNames: make(map[int]bool),
+	}
+}
+
+// Evaluate computes the cell at row and col of sheet. Constant cells return their value,
+// formula cells the value of their formula with the same types Cell.Value uses; Excel errors
+// such as #DIV/0! are returned as values of type CellDataTypeError.
+// The error is ErrCircularReference when the formula depends on its own result, ErrUnsupportedFormula
+// when it needs a function or token the evaluator does not support, e.g. an add-in function or a data table.
+func (e *Evaluator) Evaluate(sheet *Sheet, row, col int) (value interface{}, dataType CellDataType, err error) {
+	cell := sheet.cell(row, col)
+	if cell == nil {
+		return nil, "", nil
+	}
+	if cell.formula == nil {
+		return cell.value, cell.dataType, nil
+	}
+
+	result, err := e.evalCell(sheet, row, col, cell)
+	if err != nil {
+		return nil, "", err
+	}
+	value, dataType = cellResult(result)
+	return value, dataType, nil
+}
+
+// Recalculate replaces the values of all formula cells by the results of their formulas.
+// Cells with formulas that cannot be decoded or evaluated, or that depend on their own result, keep the value
+// stored in the file; the first circular reference found in sheet, row and column order is returned.
+func (xls *XLS) Recalculate() error {
+	e := NewEvaluator(xls)
+
+	var firstErr error
+	for _, sheet := range xls.sheets {
+		for row := 0; row <= sheet.maxRow; row++ {
+			r, ok := sheet.rows[row]
+			if !ok {
+				continue
+			}
+			// in column order, so that the first error is the same on every run
+			for col := 0; col <= sheet.maxCol; col++ {
+				cell, ok := r.cells[col]
+				if !ok || cell.formula == nil {
+					continue
+				}
+				value, dataType, err := e.Evaluate(sheet, row, col)
+				if errors.Is(err, ErrUnsupportedFormula) {
+					continue
+				}
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+					}
+					continue
+				}
+				cell.setValue(value, dataType)
+			}
+		}
+	}
+
+	return firstErr
+}
+
+// evalCell returns the result of the formula of a cell, evaluating it on first use.
+func (e *Evaluator) evalCell(sheet *Sheet, row, col int, cell *Cell) (interface{}, error) {
+	if value, ok := e.values[cell]; ok {
+		return value, nil
+	}
+	if err, ok := e.failed[cell]; ok {
+		return nil, err
+	}
+	if e.active[cell] {
+		return nil, circularError(sheet, row, col)
+	}
+
+	e.active[cell] = true
+	defer delete(e.active, cell)
+
+	ctx := &evalContext{sheet: sheet, row: row, col: col, array: cell.arrayRange != nil}
+	value := e.eval(cell.formula, ctx)
+
+	// the cell keeps a single value: references are intersected, arrays give the element for the cell
+	value = e.scalar(value, ctx)
+	if arr, ok := value.([][]interface{}); ok {
+		value = arrayElement(arr, cell.arrayRange, row, col)
+	}
+
+	if err := e.err; err != nil {
+		e.err = nil
+		e.failed[cell] = err
+		return nil, err
+	}
+	e.values[cell] = value
+	return value, nil
+}
+
+// fail stops the evaluation of the current cell with err, the first error is kept.
+func (e *Evaluator) fail(err error) {
+	if e.err == nil {
+		e.err = err
+	}
+}
+
+func circularError(sheet *Sheet, row, col int) error {
+	return fmt.Errorf("%w: %s!%s", ErrCircularReference, quoteSheetName(sheet.name),
+		formatCellAddress(row, col, true, true))
+}
+
+// exprNode is an operator, function or operand of an expression with the nodes of its operands.
+type exprNode struct {
+	token *ptg
+	args  []*exprNode
+}
+
+// exprTree builds the tree of an expression from its tokens in reverse Polish notation.
+// Parentheses, spaces and the jumps of IF and CHOOSE are left out.
+func exprTree(tokens []ptg) (*exprNode, bool) {
+	var stack []*exprNode
+	for i := range tokens {
+		token := &tokens[i]
+
+		argc := 0
+		switch token.id {
+		case ptgAttr:
+			if token.attr&ptgAttrSum == 0 {
+				continue
+			}
+			argc = 1
+		case ptgParen:
+			continue
+		case ptgAdd, ptgSub, ptgMul, ptgDiv, ptgPower, ptgConcat, ptgLT, ptgLE, ptgEQ, ptgGE, ptgGT, ptgNE,
+			ptgRange, ptgUnion, ptgIsect:
+			argc = 2
+		case ptgUplus, ptgUminus, ptgPercent:
+			argc = 1
+		case ptgFunc, ptgFuncVar:
+			argc = token.argc
+		}
+		if argc > len(stack) {
+			return nil, false
+		}
+
+		node := &exprNode{token: token, args: append([]*exprNode(nil), stack[len(stack)-argc:]...)}
+		stack = append(stack[:len(stack)-argc], node)
+	}
+
+	if len(stack) != 1 {
+		return nil, false
+	}
+	return stack[0], true
+}
+
+// eval runs the tokens of a parsed expression.
+func (e *Evaluator) eval(tokens []ptg, ctx *evalContext) interface{} {
+	root, ok := exprTree(tokens)
+	if !ok {
+		return errValue
+	}
+	return e.evalNode(root, ctx)
+}
+
+func (e *Evaluator) evalNode(node *exprNode, ctx *evalContext) interface{} {
+	if e.err != nil {
+		return errValue
+	}
+
+	token := node.token
+	switch token.id {
+	case ptgAttr:
+		// tAttrSum, SUM with a single argument
+		return fnSum(e, ctx, []interface{}{e.evalNode(node.args[0], ctx)})
+
+	case ptgAdd, ptgSub, ptgMul, ptgDiv, ptgPower, ptgConcat, ptgLT, ptgLE, ptgEQ, ptgGE, ptgGT, ptgNE:
+		a := e.evalNode(node.args[0], ctx)
+		b := e.evalNode(node.args[1], ctx)
+		return e.binary(token.id, a, b, ctx)
+
+	case ptgRange, ptgUnion, ptgIsect:
+		a := e.evalNode(node.args[0], ctx)
+		b := e.evalNode(node.args[1], ctx)
+		return referenceOperator(token.id, a, b)
+
+	case ptgUplus, ptgUminus, ptgPercent:
+		return e.unary(token.id, e.evalNode(node.args[0], ctx), ctx)
+
+	case ptgMissArg:
+		return missingArg{}
+
+	case ptgStr, ptgErr, ptgBool, ptgInt, ptgNum, ptgArray, ptgRefErr, ptgAreaErr, ptgRefErr3d, ptgAreaErr3d:
+		return token.value
+
+	case ptgRef, ptgArea, ptgRefN, ptgAreaN, ptgRef3d, ptgArea3d:
+		if token.area == nil {
+			return token.value
+		}
+		return e.operand(e.reference(token.area, ctx), token.class, ctx)
+
+	case ptgName, ptgNameX:
+		return e.operand(e.name(token.index, ctx), token.class, ctx)
+
+	case ptgMemArea, ptgMemErr, ptgMemNoMem, ptgMemFunc:
+		return e.eval(token.sub, ctx)
+
+	case ptgFunc, ptgFuncVar:
+		if token.text == "" {
+			// add-in and macro functions are not available
+			e.fail(ErrUnsupportedFormula)
+			return errName
+		}
+
+		args := make([]interface{}, len(node.args))
+		selected, lazy := 0, false
+		for i := range args {
+			if lazy && i != selected {
+				args[i] = missingArg{}
+				continue
+			}
+			args[i] = e.evalNode(node.args[i], ctx)
+			if i == 0 {
+				selected, lazy = e.selectArg(token.text, args[0], ctx)
+			}
+		}
+		return e.call(token.text, args, ctx)
+	}
+
+	// tExp and tTbl left unresolved
+	e.fail(ErrUnsupportedFormula)
+	return errNA
+}
+
+// selectArg returns the only argument after the first that IF and CHOOSE evaluate, from the value
+// of the first; the other arguments are passed as missingArg. ok is false for other functions.
+func (e *Evaluator) selectArg(name string, first interface{}, ctx *evalContext) (selected int, ok bool) {
+	switch name {
+	case "IF":
+		cond, err := toBool(e.scalar(first, ctx))
+		switch {
+		case err != "":
+			return 0, true
+		case cond:
+			return 1, true
+		}
+		return 2, true
+	case "CHOOSE":
+		index, err := toNumber(e.scalar(first, ctx))
+		if err != "" {
+			return 0, true
+		}
+		return int(index), true
+	}
+	return 0, false
+}
+
+func (e *Evaluator) call(name string, args []interface{}, ctx *evalContext) interface{} {
+	fn, ok := builtins[name]
+	if !ok {
+		e.fail(ErrUnsupportedFormula)
+		return errName
+	}
+	return fn(e, ctx, args)
+}
+
+// reference resolves the area of a reference token against the formula cell.
+func (e *Evaluator) reference(a *area, ctx *evalContext) interface{} {
+	if a.firstSheet < 0 {
+		return reference{{sheet: ctx.sheet, rng: a.rng}}
+	}
+
+	var ref reference
+	for i := a.firstSheet; i <= a.lastSheet; i++ {
+		if i >= len(e.xls.sheets) {
+			return errRef
+		}
+		ref = append(ref, sheetArea{sheet: e.xls.sheets[i], rng: a.rng})
+	}
+	return ref
+}
+
+// operand converts a reference to the operand class the token asks for.
+func (e *Evaluator) operand(value interface{}, class byte, ctx *evalContext) interface{} {
+	ref, ok := value.(reference)
+	if !ok {
+		return value
+	}
+
+	switch {
+	case class == ptgClassArray, class == ptgClassValue && ctx.array:
+		return e.toArray(ref)
+	case class == ptgClassValue:
+		return e.intersect(ref, ctx)
+	}
+	return ref
+}
+
+// name evaluates the formula of a defined name.
+func (e *Evaluator) name(index int, ctx *evalContext) interface{} {
+	if index < 0 || index >= len(e.xls.definedNames) {
+		return errName
+	}
+	if e.activeNames[index] {
+		e.fail(circularError(ctx.sheet, ctx.row, ctx.col))
+		return errName
+	}
+
+	e.activeNames[index] = true
+	defer delete(e.activeNames, index)
+
+	name := e.xls.definedNames[index]
+	tokens, err := e.xls.parseFormula(name.formula, name.extra, ctx.row, ctx.col)
+	if err != nil {
+		e.fail(ErrUnsupportedFormula)
+		return errName
+	}
+	if len(tokens) == 0 {
+		return errName
+	}
+	return e.eval(tokens, ctx)
+}
+
+// cellValue returns the value of a cell for use in a formula: nil for empty cells,
+// float64, string, bool or formulaError otherwise.
+func (e *Evaluator) cellValue(sheet *Sheet, row, col int) interface{} {
+	cell := sheet.cell(row, col)
+	if cell == nil {
+		return nil
+	}
+	if cell.formula != nil {
+		if e.err != nil {
+			return errValue
+		}
+		value, err := e.evalCell(sheet, row, col, cell)
+		if err != nil {
+			e.fail(err)
+			return errValue
+		}
+		return value
+	}
+
+	switch cell.dataType {
+	case CellDataTypeBool:
+		b, _ := cell.value.(uint8)
+		return b != 0
+	case CellDataTypeError:
+		s, _ := cell.value.(string)
+		return formulaError(s)
+	}
+	return cell.value
+}
+
+// scalar reduces a reference to a single value by implicit intersection, other values are returned as is.
+func (e *Evaluator) scalar(value interface{}, ctx *evalContext) interface{} {
+	if ref, ok := value.(reference); ok {
+		return e.intersect(ref, ctx)
+	}
+	return value
+}
+
+// intersect returns the value of a single cell reference, or of the cell of a single row or column
+// in the row or column of the formula cell.
+func (e *Evaluator) intersect(ref reference, ctx *evalContext) interface{} {
+	if len(ref) != 1 {
+		return errValue
+	}
+	a := ref[0]
+	switch {
+	case a.rng.FirstRow == a.rng.LastRow && a.rng.FirstCol == a.rng.LastCol:
+		return e.cellValue(a.sheet, a.rng.FirstRow, a.rng.FirstCol)
+	case a.rng.FirstCol == a.rng.LastCol && ctx.row >= a.rng.FirstRow && ctx.row <= a.rng.LastRow:
+		return e.cellValue(a.sheet, ctx.row, a.rng.FirstCol)
+	case a.rng.FirstRow == a.rng.LastRow && ctx.col >= a.rng.FirstCol && ctx.col <= a.rng.LastCol:
+		return e.cellValue(a.sheet, a.rng.FirstRow, ctx.col)
+	}
+	return errValue
+}
+
+// toArray returns the values of a single area reference by row. Areas reaching past the used part
+// of the sheet, e.g. entire columns, are cut at the last used row and column.
+func (e *Evaluator) toArray(ref reference) interface{} {
+	if len(ref) != 1 {
+		return errValue
+	}
+	a := ref[0]
+	lastRow := min(a.rng.LastRow, max(a.rng.FirstRow, a.sheet.maxRow))
+	lastCol := min(a.rng.LastCol, max(a.rng.FirstCol, a.sheet.maxCol))
+
+	arr := make([][]interface{}, 0, lastRow-a.rng.FirstRow+1)
+	for row := a.rng.FirstRow; row <= lastRow; row++ {
+		values := make([]interface{}, 0, lastCol-a.rng.FirstCol+1)
+		for col := a.rng.FirstCol; col <= lastCol; col++ {
+			values = append(values, e.cellValue(a.sheet, row, col))
+		}
+		arr = append(arr, values)
+	}
+	return arr
+}
+
+// each calls fn for the values of a function argument: the non-empty cells of references,
+// the elements of arrays or the argument itself. direct tells whether the value was passed directly,
+// many functions ignore text and booleans found in references but convert them when passed directly.
+func (e *Evaluator) each(arg interface{}, fn func(value interface{}, direct bool)) {
+	switch v := arg.(type) {
+	case reference:
+		for _, a := range v {
+			lastRow := min(a.rng.LastRow, a.sheet.maxRow)
+			for row := a.rng.FirstRow; row <= lastRow; row++ {
+				r, ok := a.sheet.rows[row]
+				if !ok {
+					continue
+				}
+				lastCol := min(a.rng.LastCol, a.sheet.maxCol)
+				for col := a.rng.FirstCol; col <= lastCol; col++ {
+					if c, ok := r.cells[col]; !ok || (c.value == nil && c.formula == nil) {
+						continue
+					}
+					if value := e.cellValue(a.sheet, row, col); value != nil {
+						fn(value, false)
+					}
+				}
+			}
+		}
+	case [][]interface{}:
+		for _, values := range v {
+			for _, value := range values {
+				if value != nil {
+					fn(value, false)
+				}
+			}
+		}
+	case missingArg:
+	default:
+		fn(v, true)
+	}
+}
+
+func (e *Evaluator) binary(id byte, a, b interface{}, ctx *evalContext) interface{} {
+	a, b = e.scalarOrArray(a, ctx), e.scalarOrArray(b, ctx)
+
+	arrA, okA := a.([][]interface{})
+	arrB, okB := b.([][]interface{})
+	if okA || okB {
+		// element by element, a single row or column is repeated to the size of the other array
+		if !okA {
+			arrA = [][]interface{}{{a}}
+		}
+		if !okB {
+			arrB = [][]interface{}{{b}}
+		}
+		rows := max(len(arrA), len(arrB))
+		cols := max(arrayWidth(arrA), arrayWidth(arrB))
+		result := make([][]interface{}, rows)
+		for r := range result {
+			result[r] = make([]interface{}, cols)
+			for c := range result[r] {
+				result[r][c] = binaryScalar(id, broadcast(arrA, r, c), broadcast(arrB, r, c))
+			}
+		}
+		return result
+	}
+
+	return binaryScalar(id, a, b)
+}
+
+func (e *Evaluator) unary(id byte, a interface{}, ctx *evalContext) interface{} {
+	a = e.scalarOrArray(a, ctx)
+
+	if arr, ok := a.([][]interface{}); ok {
+		result := make([][]interface{}, len(arr))
+		for r := range arr {
+			result[r] = make([]interface{}, len(arr[r]))
+			for c := range arr[r] {
+				result[r][c] = unaryScalar(id, arr[r][c])
+			}
+		}
+		return result
+	}
+
+	return unaryScalar(id, a)
+}
+
+// scalarOrArray prepares an operator operand: references become arrays in array formulas
+// and single values otherwise.
+func (e *Evaluator) scalarOrArray(value interface{}, ctx *evalContext) interface{} {
+	switch v := value.(type) {
+	case reference:
+		if ctx.array {
+			return e.toArray(v)
+		}
+		return e.intersect(v, ctx)
+	case missingArg:
+		return nil
+	}
+	return value
+}
+
+func binaryScalar(id byte, a, b interface{}) interface{} {
+	if err, ok := a.(formulaError); ok {
+		return err
+	}
+	if err, ok := b.(formulaError); ok {
+		return err
+	}
+
+	switch id {
+	case ptgConcat:
+		return toText(a) + toText(b)
+	case ptgLT, ptgLE, ptgEQ, ptgGE, ptgGT, ptgNE:
+		c := compareValues(a, b)
+		switch id {
+		case ptgLT:
+			return c < 0
+		case ptgLE:
+			return c <= 0
+		case ptgEQ:
+			return c == 0
+		case ptgGE:
+			return c >= 0
+		case ptgGT:
+			return c > 0
+		}
+		return c != 0
+	}
+
+	x, err := toNumber(a)
+	if err != "" {
+		return err
+	}
+	y, err := toNumber(b)
+	if err != "" {
+		return err
+	}
+
+	switch id {
+	case ptgAdd:
+		return x + y
+	case ptgSub:
+		return x - y
+	case ptgMul:
+		return x * y
+	case ptgDiv:
+		if y == 0 {
+			return errDiv0
+		}
+		return x / y
+	case ptgPower:
+		if x == 0 && y < 0 {
+			return errDiv0
+		}
+		return checkNumber(math.Pow(x, y))
+	}
+	return errValue
+}
+
+func unaryScalar(id byte, a interface{}) interface{} {
+	x, err := toNumber(a)
+	if err != "" {
+		return err
+	}
+	switch id {
+	case ptgUminus:
+		return -x
+	case ptgPercent:
+		return x / 100
+	}
+	return x
+}
+
+// referenceOperator applies the range (:), union (,) and intersection (space) operators.
+func referenceOperator(id byte, a, b interface{}) interface{} {
+	refA, okA := a.(reference)
+	refB, okB := b.(reference)
+	if !okA || !okB {
+		if err, ok := a.(formulaError); ok {
+			return err
+		}
+		if err, ok := b.(formulaError); ok {
+			return err
+		}
+		return errValue
+	}
+
+	if id == ptgUnion {
+		return append(append(reference{}, refA...), refB...)
+	}
+
+	if len(refA) != 1 || len(refB) != 1 || refA[0].sheet != refB[0].sheet {
+		return errValue
+	}
+	x, y := refA[0].rng, refB[0].rng
+
+	if id == ptgRange {
+		// smallest area containing both
+		return reference{{sheet: refA[0].sheet, rng: Range{
+			FirstRow: min(x.FirstRow, y.FirstRow),
+			LastRow:  max(x.LastRow, y.LastRow),
+			FirstCol: min(x.FirstCol, y.FirstCol),
+			LastCol:  max(x.LastCol, y.LastCol),
+		}}}
+	}
+
+	rng := Range{
+		FirstRow: max(x.FirstRow, y.FirstRow),
+		LastRow:  min(x.LastRow, y.LastRow),
+		FirstCol: max(x.FirstCol, y.FirstCol),
+		LastCol:  min(x.LastCol, y.LastCol),
+	}
+	if rng.FirstRow > rng.LastRow || rng.FirstCol > rng.LastCol {
+		return errNull
+	}
+	return reference{{sheet: refA[0].sheet, rng: rng}}
+}
+
+// cellResult converts the result of a formula into a cell value and its data type.
+func cellResult(value interface{}) (interface{}, CellDataType) {
+	switch v := value.(type) {
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return string(errNum), CellDataTypeError
+		}
+		return v, CellDataTypeNumeric
+	case string:
+		return v, CellDataTypeString
+	case bool:
+		if v {
+			return uint8(1), CellDataTypeBool
+		}
+		return uint8(0), CellDataTypeBool
+	case formulaError:
+		return string(v), CellDataTypeError
+	case nil, missingArg:
+		// a formula referring to an empty cell shows 0
+		return float64(0), CellDataTypeNumeric
+	}
+	return string(errValue), CellDataTypeError
+}
+
+// arrayElement picks the element of an array result for a cell of an array formula.
+func arrayElement(arr [][]interface{}, rng *Range, row, col int) interface{} {
+	r, c := 0, 0
+	if rng != nil {
+		r, c = row-rng.FirstRow, col-rng.FirstCol
+	}
+	if len(arr) == 1 {
+		r = 0
+	}
+	if arrayWidth(arr) == 1 {
+		c = 0
+	}
+	if r >= len(arr) || c >= len(arr[r]) {
+		return errNA
+	}
+	return arr[r][c]
+}
+
+func arrayWidth(arr [][]interface{}) int {
+	if len(arr) == 0 {
+		return 0
+	}
+	return len(arr[0])
+}
+
+// broadcast returns the element at r, c, repeating single rows and columns.
+func broadcast(arr [][]interface{}, r, c int) interface{} {
+	if len(arr) == 1 {
+		r = 0
+	}
+	if arrayWidth(arr) == 1 {
+		c = 0
+	}
+	if r >= len(arr) || c >= len(arr[r]) {
+		return errNA
+	}
+	return arr[r][c]
+}
+
+// toNumber converts a single value to a number, the error is empty on success.
+func toNumber(value interface{}) (float64, formulaError) {
+	switch v := value.(type) {
+	case nil, missingArg:
+		return 0, ""
+	case float64:
+		return v, ""
+	case bool:
+		if v {
+			return 1, ""
+		}
+		return 0, ""
+	case string:
+		if n, ok := parseNumber(v); ok {
+			return n, ""
+		}
+		return 0, errValue
+	case formulaError:
+		return 0, v
+	}
+	return 0, errValue
+}
+
+// parseNumber reads text as a number the way Excel converts text in arithmetic, e.g. " 1,234.5 " or "12%".
+func parseNumber(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	percent := strings.HasSuffix(s, "%")
+	if percent {
+		s = strings.TrimSpace(strings.TrimSuffix(s, "%"))
+	}
+	s = strings.ReplaceAll(s, ",", "")
+	if s == "" {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false
+	}
+	if percent {
+		n /= 100
+	}
+	return n, true
+}
+
+// toText converts a single value to text the way the & operator does.
+func toText(value interface{}) string {
+	switch v := value.(type) {
+	case float64:
+		return formatGeneralNumber(v)
+	case string:
+		return v
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case formulaError:
+		return string(v)
+	}
+	return ""
+}
+
+// toBool converts a single value to a boolean, the error is empty on success.
+func toBool(value interface{}) (bool, formulaError) {
+	switch v := value.(type) {
+	case nil, missingArg:
+		return false, ""
+	case bool:
+		return v, ""
+	case float64:
+		return v != 0, ""
+	case string:
+		switch strings.ToUpper(v) {
+		case "TRUE":
+			return true, ""
+		case "FALSE":
+			return false, ""
+		}
+		return false, errValue
+	case formulaError:
+		return false, v
+	}
+	return false, errValue
+}
+
+// formatGeneralNumber renders a number with at most 15 significant digits like Excel's General format in text.
+func formatGeneralNumber(v float64) string {
+	rounded, err := strconv.ParseFloat(strconv.FormatFloat(v, 'g', 15, 64), 64)
+	if err != nil {
+		rounded = v
+	}
+	return formatFormulaNumber(rounded)
+}
+
+// compareValues orders two single values like Excel: numbers before text before booleans,
+// text compared case-insensitively. Empty values compare as 0, "" or FALSE depending on the other value.
+func compareValues(a, b interface{}) int {
+	if _, ok := a.(missingArg); ok {
+		a = nil
+	}
+	if _, ok := b.(missingArg); ok {
+		b = nil
+	}
+	if a == nil {
+		a = zeroLike(b)
+	}
+	if b == nil {
+		b = zeroLike(a)
+	}
+
+	rank := func(v interface{}) int {
+		switch v.(type) {
+		case float64:
+			return 0
+		case string:
+			return 1
+		case bool:
+			return 2
+		}
+		return 3
+	}
+	if ra, rb := rank(a), rank(b); ra != rb {
+		return ra - rb
+	}
+
+	switch x := a.(type) {
+	case float64:
+		y := b.(float64)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(strings.ToLower(x), strings.ToLower(b.(string)))
+	case bool:
+		y := b.(bool)
+		switch {
+		case x == y:
+			return 0
+		case !x:
+			return -1
+		}
+		return 1
+	}
+	return 0
+}
+
+func zeroLike(v interface{}) interface{} {
+	switch v.(type) {
+	case string:
+		return ""
+	case bool:
+		return false
+	}
+	return float64(0)
+}
+
+// checkNumber turns results that are not finite into #NUM!.
+func checkNumber(v float64) interface{} {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return errNum
+	}
+	return v
+}
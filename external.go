@@ -0,0 +1,125 @@
+package xls
+
+import "strings"
+
+// Kinds of workbooks an EXTERNALBOOK record refers to.
+const (
+	ExternalBookInternal = iota // the workbook itself, used by 3D references to its own sheets
+	ExternalBookAddIn           // add-in functions
+	ExternalBookExternal        // another workbook
+	ExternalBookLink            // DDE or OLE link
+)
+
+// ExternalBook is a workbook referenced by formulas and names, from an EXTERNALBOOK record
+// and the EXTERNNAME records following it.
+type ExternalBook struct {
+	Type int // ExternalBookInternal, ExternalBookAddIn, ...
+
+	// Path is the file name or URL of an external workbook, e.g. `..\prices.xls` or `\\server\share\prices.xls`,
+	// for DDE and OLE links the application and the document separated by '|', e.g. `Excel|C:\book.xls`
+	Path string
+
+	// Sheets are the sheet names of an external workbook
+	Sheets []string
+
+	// Names are the names used from the workbook: defined names of an external workbook,
+	// add-in functions, or the items of DDE and OLE links
+	Names []ExternalName
+}
+
+// ExternalName is a name of an external workbook, from an EXTERNNAME record.
+type ExternalName struct {
+	Name string
+
+	// Sheet is the zero-based index of the sheet of the external workbook the name is local to,
+	// -1 for global names and the names of other books
+	Sheet int
+
+	BuiltIn bool
+	// OLE is set for the items of OLE links
+	OLE bool
+}
+
+// externalBook is a workbook referenced by formulas, including the workbook itself.
+type externalBook struct {
+	typ int
+
+	// url is the encoded document name of external workbooks and DDE/OLE links
+	url string
+
+	sheetNames []string
+
+	// names lists the EXTERNNAME records following the EXTERNALBOOK record
+	names []ExternalName
+}
+
+// externSheet is an entry of the EXTERNSHEET table: a range of sheets in an external book.
+type externSheet struct {
+	// book is the index into the external books
+	book int
+
+	// firstSheet and lastSheet index the sheets of the book
+	firstSheet int
+	lastSheet  int
+}
+
+// ExternalBooks returns the EXTERNALBOOK records of the workbook in file order. The entries with the type
+// ExternalBookExternal and ExternalBookLink are the files the workbook links to.
+func (xls *XLS) ExternalBooks() []ExternalBook {
+	books := make([]ExternalBook, 0, len(xls.externalBooks))
+	for _, book := range xls.externalBooks {
+		books = append(books, ExternalBook{
+			Type:   book.typ,
+			Path:   decodeBookPath(book.url, book.typ == ExternalBookLink),
+			Sheets: append([]string(nil), book.sheetNames...),
+			Names:  append([]ExternalName(nil), book.names...),
+		})
+	}
+	return books
+}
+
+// decodeBookPath decodes the encoded document name of an EXTERNALBOOK record. In encoded names control
+// characters stand for drives, directory separators and parent directories; link names separate
+// the application from the document by 0x03.
+func decodeBookPath(url string, link bool) string {
+	if link {
+		return strings.ReplaceAll(url, "\x03", "|")
+	}
+	if !strings.HasPrefix(url, "\x01") {
+		// 0x02 refers to the workbook itself, other names are not encoded
+		return strings.TrimPrefix(url, "\x02")
+	}
+
+	var sb strings.Builder
+	chars := []rune(url[1:])
+	for i := 0; i < len(chars); i++ {
+		switch chars[i] {
+		case 0x01:
+			// drive letter, or '@' for a UNC path
+			if i+1 < len(chars) {
+				i++
+				if chars[i] == '@' {
+					sb.WriteString(`\\`)
+				} else {
+					sb.WriteString(string(chars[i]) + `:\`)
+				}
+			}
+		case 0x02, 0x03:
+			// root directory of the drive, directory separator
+			sb.WriteString(`\`)
+		case 0x04:
+			// parent directory
+			sb.WriteString(`..\`)
+		case 0x05:
+			// URL, preceded by its character count
+			if i+1 < len(chars) {
+				return sb.String() + string(chars[i+2:])
+			}
+		case 0x06, 0x07, 0x08:
+			// startup, alternate startup and library directories of Excel, unknown here
+		default:
+			sb.WriteRune(chars[i])
+		}
+	}
+	return sb.String()
+}
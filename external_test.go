@@ -0,0 +1,56 @@
+package xls
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeBookPath(t *testing.T) {
+	cases := []struct {
+		url  string
+		link bool
+		want string
+	}{
+		{"\x01\x01Cdata\x03prices.xls", false, `C:\data\prices.xls`},
+		{"\x01\x01@server\x03share\x03prices.xls", false, `\\server\share\prices.xls`},
+		{"\x01\x02data\x03prices.xls", false, `\data\prices.xls`},
+		{"\x01\x04\x04prices.xls", false, `..\..\prices.xls`},
+		{"\x01prices.xls", false, "prices.xls"},
+		{"\x01\x05\x1Ahttp://example.org/book.xls", false, "http://example.org/book.xls"},
+		{"\x01\x06prices.xls", false, "prices.xls"},
+		{"\x02", false, ""},
+		{"\x02Sheet1", false, "Sheet1"},
+		{"prices.xls", false, "prices.xls"},
+		{"Excel\x03C:\\book.xls", true, `Excel|C:\book.xls`},
+	}
+	for _, c := range cases {
+		if got := decodeBookPath(c.url, c.link); got != c.want {
+			t.Errorf("decodeBookPath(%q, %v) = %q, want %q", c.url, c.link, got, c.want)
+		}
+	}
+}
+
+func TestExternalBooks(t *testing.T) {
+	book := &testBook{globals: []record{
+		rec(XLS_TYPE_EXTERNALBOOK, uint16(1), uint8(1), uint8(4)),
+		rec(XLS_TYPE_EXTERNALBOOK, uint16(2), xlString("\x01\x04prices.xls"), xlString("Q1"), wideString("Q2 €")),
+		rec(XLS_TYPE_EXTERNNAME, uint16(0), uint16(2), uint16(0), shortString("Rate"), uint16(0)),
+		rec(XLS_TYPE_EXTERNNAME, uint16(0x0001), uint16(0), uint16(0), shortString("Total"), uint16(0)),
+		rec(XLS_TYPE_EXTERNALBOOK, uint16(1), uint8(1), uint8(0x3A)),
+		rec(XLS_TYPE_EXTERNNAME, uint16(0), uint32(0), shortString("EOMONTH"), uint16(0)),
+		rec(XLS_TYPE_EXTERNALBOOK, uint16(0), xlString("Excel\x03C:\\book.xls")),
+		rec(XLS_TYPE_EXTERNNAME, uint16(0x0010), uint32(0), shortString("Sheet1!R1C1"), uint16(0)),
+	}, sheets: []testSheet{{name: "S"}}}
+
+	want := []ExternalBook{
+		{Type: ExternalBookInternal},
+		{Type: ExternalBookExternal, Path: `..\prices.xls`, Sheets: []string{"Q1", "Q2 €"}, Names: []ExternalName{
+			{Name: "Rate", Sheet: 1}, {Name: "Total", Sheet: -1, BuiltIn: true},
+		}},
+		{Type: ExternalBookAddIn, Names: []ExternalName{{Name: "EOMONTH", Sheet: -1}}},
+		{Type: ExternalBookLink, Path: `Excel|C:\book.xls`, Names: []ExternalName{{Name: "Sheet1!R1C1", Sheet: -1, OLE: true}}},
+	}
+	if got := openTestBook(t, book).ExternalBooks(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+}
@@ -0,0 +1,52 @@
+package xls
+
+const (
+	FontUnderlineNone             = 0x00
+	FontUnderlineSingle           = 0x01
+	FontUnderlineDouble           = 0x02
+	FontUnderlineSingleAccounting = 0x21
+	FontUnderlineDoubleAccounting = 0x22
+)
+
+const (
+	FontScriptNone        = 0x00
+	FontScriptSuperscript = 0x01
+	FontScriptSubscript   = 0x02
+)
+
+// Font is a font of the workbook from a FONT record.
+type Font struct {
+	Name string
+	// Size is the height of the font in points
+	Size float64
+
+	// Weight ranges from 100 to 1000, 400 is normal and 700 bold
+	Weight    int
+	Bold      bool
+	Italic    bool
+	Strikeout bool
+	Underline int // FontUnderlineNone, FontUnderlineSingle, ...
+	Script    int // FontScriptNone, FontScriptSuperscript or FontScriptSubscript
+
+	Color Color
+
+	// Family is the font family: 0 not applicable, 1 roman, 2 swiss, 3 modern, 4 script, 5 decorative
+	Family int
+	// Charset is the character set, e.g. 0 ANSI, 1 default, 2 symbol
+	Charset int
+}
+
+// font returns the font with an index used by XF records, with its color resolved. Index 4 is not used
+// by Excel, so the fourth FONT record has index 5. It returns nil for an unknown index.
+func (xls *XLS) font(index int) *Font {
+	if index >= 4 {
+		index--
+	}
+	if index < 0 || index >= len(xls.fonts) {
+		return nil
+	}
+
+	font := *xls.fonts[index]
+	font.Color = xls.color(font.Color.Index)
+	return &font
+}
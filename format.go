@@ -0,0 +1,889 @@
+package xls
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// builtInFormats are the number formats with fixed indexes that workbooks do not need to store in FORMAT records.
+var builtInFormats = map[int]string{
+	0:  "General",
+	1:  "0",
+	2:  "0.00",
+	3:  "#,##0",
+	4:  "#,##0.00",
+	5:  `"$"#,##0_);\("$"#,##0\)`,
+	6:  `"$"#,##0_);[Red]\("$"#,##0\)`,
+	7:  `"$"#,##0.00_);\("$"#,##0.00\)`,
+	8:  `"$"#,##0.00_);[Red]\("$"#,##0.00\)`,
+	9:  "0%",
+	10: "0.00%",
+	11: "0.00E+00",
+	12: "# ?/?",
+	13: "# ??/??",
+	14: "m/d/yyyy",
+	15: "d-mmm-yy",
+	16: "d-mmm",
+	17: "mmm-yy",
+	18: "h:mm AM/PM",
+	19: "h:mm:ss AM/PM",
+	20: "h:mm",
+	21: "h:mm:ss",
+	22: "m/d/yyyy h:mm",
+	// 27-36 and 50-58 are date formats of Chinese, Japanese and Korean versions of Excel,
+	// the codes of the Simplified Chinese version are used
+	27: `yyyy"年"m"月"`,
+	28: `m"月"d"日"`,
+	29: `m"月"d"日"`,
+	30: "m-d-yy",
+	31: `yyyy"年"m"月"d"日"`,
+	32: `h"时"mm"分"`,
+	33: `h"时"mm"分"ss"秒"`,
+	34: `上午/下午h"时"mm"分"`,
+	35: `上午/下午h"时"mm"分"ss"秒"`,
+	36: `yyyy"年"m"月"`,
+	37: "#,##0_);(#,##0)",
+	38: "#,##0_);[Red](#,##0)",
+	39: "#,##0.00_);(#,##0.00)",
+	40: "#,##0.00_);[Red](#,##0.00)",
+	41: `_(* #,##0_);_(* \(#,##0\);_(* "-"_);_(@_)`,
+	42: `_("$"* #,##0_);_("$"* \(#,##0\);_("$"* "-"_);_(@_)`,
+	43: `_(* #,##0.00_);_(* \(#,##0.00\);_(* "-"??_);_(@_)`,
+	44: `_("$"* #,##0.00_);_("$"* \(#,##0.00\);_("$"* "-"??_);_(@_)`,
+	45: "mm:ss",
+	46: "[h]:mm:ss",
+	47: "mm:ss.0",
+	48: "##0.0E+0",
+	49: "@",
+	50: `yyyy"年"m"月"`,
+	51: `m"月"d"日"`,
+	52: `yyyy"年"m"月"`,
+	53: `m"月"d"日"`,
+	54: `m"月"d"日"`,
+	55: `上午/下午h"时"mm"分"`,
+	56: `上午/下午h"时"mm"分"ss"秒"`,
+	57: `yyyy"年"m"月"`,
+	58: `m"月"d"日"`,
+}
+
+// formatLiterals matches the parts of a format code that are displayed as is:
+// quoted text, escaped characters, fill and padding characters, and bracketed colors,
+// conditions and currency symbols (elapsed time [h], [m] and [s] is kept).
+var formatLiterals = regexp.MustCompile(`"[^"]*"|\\.|[_*].|\[(?:[^\]hHmMsS][^\]]*|[hHmMsS][^\]]*[^\]hHmMsS][^\]]*)\]`)
+
+// isDateFormat tells whether a number format code displays numbers as date or time.
+func isDateFormat(code string) bool {
+	if code == "" || strings.EqualFold(code, "General") || code == "@" {
+		return false
+	}
+
+	// only the section for positive numbers decides
+	code = formatLiterals.ReplaceAllString(code, "")
+	if i := strings.IndexByte(code, ';'); i >= 0 {
+		code = code[:i]
+	}
+
+	return strings.ContainsAny(code, "dDmMyYhHsS")
+}
+
+// numberFormat returns the format code of an XF record, General if the XF record or its format does not exist.
+func (xls *XLS) numberFormat(xfIndex int) string {
+	x := xls.attributeXf(xfIndex, xfAttrNumberFormat)
+	if x == nil {
+		return "General"
+	}
+
+	index := x.formatIndex
+	if code, ok := xls.formats[index]; ok {
+		return code
+	}
+	if code, ok := builtInFormats[index]; ok {
+		return code
+	}
+	return "General"
+}
+
+// kinds of the tokens of a number format section
+const (
+	fmtLiteral  = iota // text displayed as is
+	fmtDigit           // digit placeholder 0, # or ?
+	fmtPoint           // decimal point
+	fmtComma           // thousands separator or scaling by 1000
+	fmtPercent         // multiplies by 100
+	fmtExponent        // E+ or E-, text holds the sign
+	fmtSlash           // fraction bar
+	fmtText            // @, the text of the cell
+	fmtGeneral         // General
+	fmtDate            // date or time part: y, m, d, h, s, or n for minutes
+	fmtElapsed         // elapsed time [h], [m] or [s]
+	fmtAmPm            // AM/PM or A/P
+)
+
+type formatToken struct {
+	kind int
+	text string
+}
+
+// formatSection is one of the sections of a format code separated by semicolons.
+type formatSection struct {
+	tokens []formatToken
+
+	// condition such as [>=100] selecting the section
+	condition      string
+	conditionValue float64
+
+	date bool
+}
+
+var formatCondition = regexp.MustCompile(`^(<=|>=|<>|<|>|=)\s*(-?[0-9.]+(?:[eE][+-]?[0-9]+)?)$`)
+
+// parseFormat splits a format code into sections of tokens.
+func parseFormat(code string) []*formatSection {
+	runes := []rune(code)
+	section := new(formatSection)
+	sections := []*formatSection{section}
+
+	literal := func(s string) {
+		section.tokens = append(section.tokens, formatToken{fmtLiteral, s})
+	}
+	token := func(kind int, s string) {
+		section.tokens = append(section.tokens, formatToken{kind, s})
+	}
+	hasPrefix := func(i int, prefix string) bool {
+		n := utf8.RuneCountInString(prefix)
+		return i+n <= len(runes) && strings.EqualFold(string(runes[i:i+n]), prefix)
+	}
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			literal(string(runes[i+1 : end]))
+			i = end
+		case r == '\\':
+			if i+1 < len(runes) {
+				i++
+				literal(string(runes[i]))
+			}
+		case r == '_':
+			// space as wide as the next character
+			i++
+			literal(" ")
+		case r == '*':
+			// fill the cell with the next character
+			i++
+		case r == '[':
+			end := i + 1
+			for end < len(runes) && runes[end] != ']' {
+				end++
+			}
+			content := string(runes[i+1 : min(end, len(runes))])
+			i = end
+			lower := strings.ToLower(content)
+			if m := formatCondition.FindStringSubmatch(content); m != nil {
+				section.condition = m[1]
+				section.conditionValue, _ = strconv.ParseFloat(m[2], 64)
+			} else if lower != "" && strings.Trim(lower, string(lower[0])) == "" && strings.ContainsAny(lower[:1], "hms") {
+				token(fmtElapsed, lower)
+			} else if strings.HasPrefix(content, "$") {
+				// currency symbol and locale, e.g. [$€-407]
+				if symbol, _, _ := strings.Cut(content[1:], "-"); symbol != "" {
+					literal(symbol)
+				}
+			}
+			// colors such as [Red] or [Color10] are not displayed
+		case r == ';':
+			section = new(formatSection)
+			sections = append(sections, section)
+		case r == '0' || r == '#' || r == '?':
+			token(fmtDigit, string(r))
+		case r == '.':
+			token(fmtPoint, ".")
+		case r == ',':
+			token(fmtComma, ",")
+		case r == '%':
+			token(fmtPercent, "%")
+		case (r == 'E' || r == 'e') && i+1 < len(runes) && (runes[i+1] == '+' || runes[i+1] == '-'):
+			i++
+			token(fmtExponent, string(runes[i]))
+		case r == '/':
+			token(fmtSlash, "/")
+		case r == '@':
+			token(fmtText, "@")
+		case hasPrefix(i, "General"):
+			token(fmtGeneral, "General")
+			i += len("General") - 1
+		case hasPrefix(i, "AM/PM"):
+			token(fmtAmPm, "AM/PM")
+			i += len("AM/PM") - 1
+		case hasPrefix(i, "A/P"):
+			token(fmtAmPm, "A/P")
+			i += len("A/P") - 1
+		case hasPrefix(i, "上午/下午"):
+			// AM/PM of Chinese formats
+			token(fmtAmPm, "上午/下午")
+			i += utf8.RuneCountInString("上午/下午") - 1
+		case strings.ContainsRune("yYmMdDhHsS", r):
+			end := i + 1
+			for end < len(runes) && unicode.ToLower(runes[end]) == unicode.ToLower(r) {
+				end++
+			}
+			token(fmtDate, strings.ToLower(string(runes[i:end])))
+			i = end - 1
+		default:
+			literal(string(r))
+		}
+	}
+
+	for _, section := range sections {
+		section.markMinutes()
+	}
+	return sections
+}
+
+// markMinutes marks the date tokens of the section and renames m tokens meaning minutes to n:
+// m directly following hours or followed by seconds are minutes.
+func (s *formatSection) markMinutes() {
+	prev := -1
+	for i, t := range s.tokens {
+		switch t.kind {
+		case fmtDate, fmtElapsed, fmtAmPm:
+			s.date = true
+		default:
+			continue
+		}
+		if t.kind != fmtDate || t.text[0] != 'm' || len(t.text) > 2 {
+			prev = i
+			continue
+		}
+
+		minutes := prev >= 0 && s.tokens[prev].text[0] == 'h'
+		for _, next := range s.tokens[i+1:] {
+			if next.kind == fmtDate || next.kind == fmtElapsed {
+				minutes = minutes || next.text[0] == 's'
+				break
+			}
+		}
+		if minutes {
+			s.tokens[i].text = strings.Repeat("n", len(t.text))
+		}
+		prev = i
+	}
+}
+
+// matches tells whether the condition of the section holds for a value.
+func (s *formatSection) matches(value float64) bool {
+	switch s.condition {
+	case "<":
+		return value < s.conditionValue
+	case "<=":
+		return value <= s.conditionValue
+	case ">":
+		return value > s.conditionValue
+	case ">=":
+		return value >= s.conditionValue
+	case "=":
+		return value == s.conditionValue
+	case "<>":
+		return value != s.conditionValue
+	}
+	return true
+}
+
+// formatNumber renders a number with a format code like Excel displays it.
+func formatNumber(value float64, code string, date1904 bool) string {
+	sections := parseFormat(code)
+	if len(sections) > 3 {
+		// the fourth section is for text
+		sections = sections[:3]
+	}
+
+	section, abs := sections[0], false
+	if sections[0].condition != "" || (len(sections) > 1 && sections[1].condition != "") {
+		// sections chosen by conditions, the value is displayed with its sign
+		switch {
+		case sections[0].matches(value):
+		case len(sections) > 1 && (sections[1].condition == "" || sections[1].matches(value)):
+			section = sections[1]
+		case len(sections) > 2:
+			section = sections[2]
+		default:
+			return strings.Repeat("#", 10)
+		}
+	} else if value < 0 && len(sections) > 1 {
+		// the second section is for negative numbers, displayed without minus sign
+		section, abs = sections[1], true
+	} else if value == 0 && len(sections) > 2 {
+		section = sections[2]
+	}
+
+	if section.date {
+		if value < 0 {
+			return generalFormat(value)
+		}
+		return section.formatDate(value, date1904)
+	}
+
+	text, zero := section.formatNumber(math.Abs(value))
+	if value < 0 && !abs && !zero {
+		text = "-" + text
+	}
+	return text
+}
+
+// formatText renders text with the text section of a format code.
+func formatText(text, code string) string {
+	sections := parseFormat(code)
+
+	var section *formatSection
+	if len(sections) > 3 {
+		section = sections[3]
+	} else if len(sections) == 1 {
+		section = sections[0]
+	}
+	if section == nil {
+		return text
+	}
+
+	hasText := false
+	var sb strings.Builder
+	for _, t := range section.tokens {
+		switch t.kind {
+		case fmtText:
+			sb.WriteString(text)
+			hasText = true
+		case fmtLiteral:
+			sb.WriteString(t.text)
+		}
+	}
+	if !hasText && len(sections) <= 3 {
+		// a number format without text placeholder displays the text as is
+		return text
+	}
+	return sb.String()
+}
+
+// formatNumber renders the absolute value of a number with a number section. zero tells
+// whether the displayed number is 0, which is displayed without minus sign.
+func (s *formatSection) formatNumber(value float64) (string, bool) {
+	tokens := s.tokens
+
+	for _, t := range tokens {
+		if t.kind == fmtGeneral {
+			var sb strings.Builder
+			for _, t := range tokens {
+				switch t.kind {
+				case fmtGeneral:
+					sb.WriteString(generalFormat(value))
+				case fmtDigit, fmtText:
+				default:
+					sb.WriteString(t.text)
+				}
+			}
+			return sb.String(), value == 0
+		}
+		if t.kind == fmtPercent {
+			value *= 100
+		}
+	}
+
+	// commas after the last digit placeholder scale by 1000, commas between digit placeholders separate thousands
+	thousands := false
+	for i, t := range tokens {
+		if t.kind == fmtExponent {
+			break
+		}
+		if t.kind != fmtComma {
+			continue
+		}
+		digitBefore, digitAfter := false, false
+		for _, before := range tokens[:i] {
+			digitBefore = digitBefore || before.kind == fmtDigit
+		}
+		for _, after := range tokens[i+1:] {
+			if after.kind == fmtExponent {
+				break
+			}
+			digitAfter = digitAfter || after.kind == fmtDigit
+		}
+		if !digitAfter {
+			value /= 1000
+		} else if digitBefore {
+			thousands = true
+		}
+	}
+
+	for i, t := range tokens {
+		if t.kind == fmtSlash && i > 0 && tokens[i-1].kind == fmtDigit {
+			return s.formatFraction(value, i)
+		}
+	}
+
+	// split into integer, decimal and exponent tokens
+	var intTokens, fracTokens, expTokens []formatToken
+	exponent := ""
+	part := &intTokens
+	for _, t := range tokens {
+		switch {
+		case t.kind == fmtPoint && part == &intTokens:
+			part = &fracTokens
+			fracTokens = append(fracTokens, t)
+		case t.kind == fmtExponent && exponent == "":
+			exponent = t.text
+			part = &expTokens
+		default:
+			*part = append(*part, t)
+		}
+	}
+	decimals := countDigits(fracTokens)
+
+	expValue := 0
+	if exponent != "" && value != 0 {
+		intDigits := max(1, countDigits(intTokens))
+		expValue = int(math.Floor(math.Log10(value)))
+		if intDigits > 1 && strings.Contains(tokensText(intTokens), "#") {
+			// engineering notation, the exponent is a multiple of the number of integer digits
+			expValue = int(math.Floor(float64(expValue)/float64(intDigits))) * intDigits
+		} else {
+			expValue -= intDigits - 1
+		}
+		value /= math.Pow(10, float64(expValue))
+		if intPart, _ := decimalDigits(value, decimals); len(intPart) > intDigits && !strings.Contains(tokensText(intTokens), "#") {
+			// rounded up to the next power of ten
+			value /= 10
+			expValue++
+		}
+	}
+
+	intPart, fracPart := decimalDigits(value, decimals)
+	zero := strings.Trim(intPart+fracPart, "0") == ""
+	if intPart == "0" {
+		intPart = ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(formatIntDigits(intTokens, intPart, thousands))
+	sb.WriteString(formatFracDigits(fracTokens, fracPart))
+	if exponent != "" {
+		sb.WriteString("E")
+		if expValue < 0 {
+			sb.WriteString("-")
+		} else if exponent == "+" {
+			sb.WriteString("+")
+		}
+		digits := strconv.Itoa(abs(expValue))
+		sb.WriteString(formatIntDigits(expTokens, digits, false))
+	}
+	return sb.String(), zero
+}
+
+// formatFraction renders a number as fraction, slash is the index of the fraction bar token.
+func (s *formatSection) formatFraction(value float64, slash int) (string, bool) {
+	tokens := s.tokens
+
+	// numerator placeholders directly precede the bar, integer placeholders come before them
+	numStart := slash
+	for numStart > 0 && tokens[numStart-1].kind == fmtDigit {
+		numStart--
+	}
+	intTokens := tokens[:numStart]
+	hasInt := countDigits(intTokens) > 0
+
+	// the denominator is given by placeholders or as a fixed number
+	denEnd := slash + 1
+	fixed := ""
+	for denEnd < len(tokens) && (tokens[denEnd].kind == fmtDigit ||
+		(tokens[denEnd].kind == fmtLiteral && len(tokens[denEnd].text) == 1 && tokens[denEnd].text[0] >= '0' && tokens[denEnd].text[0] <= '9')) {
+		if tokens[denEnd].kind == fmtLiteral {
+			fixed += tokens[denEnd].text
+		}
+		denEnd++
+	}
+	denTokens := tokens[slash+1 : denEnd]
+
+	whole, frac := 0.0, value
+	if hasInt {
+		whole = math.Floor(value)
+		frac = value - whole
+	}
+
+	var num, den int
+	if fixed != "" {
+		den, _ = strconv.Atoi(fixed)
+		if den == 0 {
+			den = 1
+		}
+		num = int(math.Round(frac * float64(den)))
+	} else {
+		// Excel limits the denominator to 4 digits as well
+		num, den = approximateFraction(frac, int(math.Pow(10, float64(min(len(denTokens), 4))))-1)
+	}
+	if hasInt && num == den {
+		whole++
+		num = 0
+	}
+
+	intPart := strconv.FormatFloat(whole, 'f', 0, 64)
+	if whole == 0 && (num != 0 || !hasInt) {
+		intPart = ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(formatIntDigits(intTokens, intPart, false))
+	if num == 0 && hasInt {
+		// no fraction, keep the width of it
+		for _, t := range tokens[numStart:denEnd] {
+			if t.text == "?" || t.kind == fmtSlash {
+				sb.WriteString(" ")
+			}
+		}
+	} else {
+		sb.WriteString(formatIntDigits(tokens[numStart:slash], strconv.Itoa(num), false))
+		sb.WriteString("/")
+		if fixed != "" {
+			sb.WriteString(fixed)
+		} else {
+			// the denominator is aligned to the left
+			digits := strconv.Itoa(den)
+			sb.WriteString(digits)
+			for _, t := range denTokens[min(len(digits), len(denTokens)):] {
+				if t.text == "?" {
+					sb.WriteString(" ")
+				}
+			}
+		}
+	}
+	for _, t := range tokens[denEnd:] {
+		if t.kind != fmtDigit {
+			sb.WriteString(t.text)
+		}
+	}
+	return sb.String(), whole == 0 && num == 0
+}
+
+// approximateFraction returns the fraction closest to value with a denominator up to maxDen.
+func approximateFraction(value float64, maxDen int) (int, int) {
+	bestNum, bestDen, bestErr := 0, 1, math.Inf(1)
+	for den := 1; den <= max(1, maxDen); den++ {
+		num := math.Round(value * float64(den))
+		if err := math.Abs(value - num/float64(den)); err < bestErr-1e-12 {
+			bestNum, bestDen, bestErr = int(num), den, err
+		}
+	}
+	return bestNum, bestDen
+}
+
+// formatIntDigits fills integer digits into the placeholders from the right. Digits
+// not fitting into the placeholders are displayed before the first one.
+func formatIntDigits(tokens []formatToken, digits string, thousands bool) string {
+	first := -1
+	for i, t := range tokens {
+		if t.kind == fmtDigit {
+			first = i
+			break
+		}
+	}
+
+	var out []string
+	pos, placed := len(digits), 0
+	emit := func(s string) {
+		if thousands && placed > 0 && placed%3 == 0 && s != " " {
+			out = append(out, ",")
+		}
+		out = append(out, s)
+		placed++
+	}
+	for i := len(tokens) - 1; i >= 0; i-- {
+		t := tokens[i]
+		switch t.kind {
+		case fmtDigit:
+			switch {
+			case pos > 0:
+				pos--
+				emit(digits[pos : pos+1])
+			case t.text == "0":
+				emit("0")
+			case t.text == "?":
+				out = append(out, " ")
+			}
+			if i == first {
+				for pos > 0 {
+					pos--
+					emit(digits[pos : pos+1])
+				}
+			}
+		case fmtComma, fmtPercent, fmtText, fmtSlash:
+			if t.kind == fmtPercent || t.kind == fmtSlash {
+				out = append(out, t.text)
+			}
+		default:
+			out = append(out, t.text)
+		}
+	}
+
+	var sb strings.Builder
+	for i := len(out) - 1; i >= 0; i-- {
+		sb.WriteString(out[i])
+	}
+	return sb.String()
+}
+
+// formatFracDigits fills decimal digits into the placeholders after the decimal point. Trailing zeros
+// are left out for # placeholders and displayed as spaces for ? placeholders.
+func formatFracDigits(tokens []formatToken, digits string) string {
+	significant := len(strings.TrimRight(digits, "0"))
+
+	var sb strings.Builder
+	pos := 0
+	for _, t := range tokens {
+		switch t.kind {
+		case fmtDigit:
+			switch {
+			case pos < significant || t.text == "0":
+				sb.WriteByte(digits[pos])
+			case t.text == "?":
+				sb.WriteByte(' ')
+			}
+			pos++
+		case fmtComma, fmtText:
+		default:
+			sb.WriteString(t.text)
+		}
+	}
+	return sb.String()
+}
+
+func countDigits(tokens []formatToken) int {
+	count := 0
+	for _, t := range tokens {
+		if t.kind == fmtDigit {
+			count++
+		}
+	}
+	return count
+}
+
+func tokensText(tokens []formatToken) string {
+	var sb strings.Builder
+	for _, t := range tokens {
+		sb.WriteString(t.text)
+	}
+	return sb.String()
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// decimalDigits rounds a non-negative number half away from zero to the given number of decimals,
+// using the 15 significant digits Excel keeps, and returns the digits before and after the decimal point.
+func decimalDigits(value float64, decimals int) (string, string) {
+	// d.dddddddddddddde±x
+	s := strconv.FormatFloat(value, 'e', 14, 64)
+	mantissa, exp, _ := strings.Cut(s, "e")
+	e, _ := strconv.Atoi(exp)
+	digits := []byte(strings.Replace(mantissa, ".", "", 1))
+
+	// position of the decimal point within digits
+	point := e + 1
+	if point < 0 {
+		digits = append([]byte(strings.Repeat("0", -point)), digits...)
+		point = 0
+	}
+	for len(digits) < point+decimals+1 {
+		digits = append(digits, '0')
+	}
+
+	// round at the first dropped digit
+	roundUp := digits[point+decimals] >= '5'
+	digits = digits[:point+decimals]
+	for i := len(digits) - 1; roundUp && i >= 0; i-- {
+		if digits[i] == '9' {
+			digits[i] = '0'
+		} else {
+			digits[i]++
+			roundUp = false
+		}
+	}
+	if roundUp {
+		digits = append([]byte{'1'}, digits...)
+		point++
+	}
+
+	intPart := strings.TrimLeft(string(digits[:point]), "0")
+	if intPart == "" {
+		intPart = "0"
+	}
+	return intPart, string(digits[point:])
+}
+
+// generalFormat renders a number with the General format: up to 11 characters,
+// very large and very small numbers in scientific notation.
+func generalFormat(value float64) string {
+	a := math.Abs(value)
+	if a == 0 {
+		return "0"
+	}
+	if a >= 1e11 || a < 1e-9 {
+		mantissa, exp, _ := strings.Cut(strconv.FormatFloat(value, 'E', 5, 64), "E")
+		if strings.Contains(mantissa, ".") {
+			mantissa = strings.TrimRight(strings.TrimRight(mantissa, "0"), ".")
+		}
+		return mantissa + "E" + exp
+	}
+
+	intDigits := len(strconv.FormatFloat(math.Floor(a), 'f', 0, 64))
+	s := strconv.FormatFloat(value, 'f', max(0, 10-intDigits), 64)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+var (
+	monthNames = []string{"January", "February", "March", "April", "May", "June", "July",
+		"August", "September", "October", "November", "December"}
+	dayNames = []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+)
+
+// formatDate renders a date serial with a date section.
+func (s *formatSection) formatDate(serial float64, date1904 bool) string {
+	// precision of the seconds from the placeholders after them, e.g. ss.00
+	precision := 0
+	for i, t := range s.tokens {
+		if t.kind == fmtPoint {
+			for _, d := range s.tokens[i+1:] {
+				if d.kind != fmtDigit {
+					break
+				}
+				precision++
+			}
+			break
+		}
+	}
+	precision = min(precision, 3)
+	scale := math.Pow(10, float64(precision))
+
+	// the time rounded to the displayed precision, as count of the smallest unit
+	units := math.Round(serial * 86400 * scale)
+	serial = units / 86400 / scale
+	t := excelTime(serial, date1904)
+	year, month, day, weekday := t.Year(), int(t.Month()), t.Day(), excelWeekday(serial, date1904)
+	if !date1904 && math.Floor(serial) == 60 {
+		// the 29 February 1900 that only exists in Excel
+		year, month, day = 1900, 2, 29
+	}
+
+	hasAmPm := false
+	for _, t := range s.tokens {
+		hasAmPm = hasAmPm || t.kind == fmtAmPm
+	}
+	totalSeconds := math.Floor(units / scale)
+	fraction := int(math.Mod(units, scale))
+
+	var sb strings.Builder
+	for i := 0; i < len(s.tokens); i++ {
+		tok := s.tokens[i]
+		n := len(tok.text)
+		switch tok.kind {
+		case fmtDate:
+			switch tok.text[0] {
+			case 'y':
+				if n <= 2 {
+					sb.WriteString(pad(year%100, 2))
+				} else {
+					sb.WriteString(pad(year, 4))
+				}
+			case 'm':
+				switch {
+				case n <= 2:
+					sb.WriteString(pad(month, n))
+				case n == 3:
+					sb.WriteString(monthNames[month-1][:3])
+				case n == 4:
+					sb.WriteString(monthNames[month-1])
+				default:
+					sb.WriteString(monthNames[month-1][:1])
+				}
+			case 'd':
+				switch {
+				case n <= 2:
+					sb.WriteString(pad(day, n))
+				case n == 3:
+					sb.WriteString(dayNames[weekday][:3])
+				default:
+					sb.WriteString(dayNames[weekday])
+				}
+			case 'h':
+				hour := t.Hour()
+				if hasAmPm {
+					hour %= 12
+					if hour == 0 {
+						hour = 12
+					}
+				}
+				sb.WriteString(pad(hour, min(n, 2)))
+			case 'n':
+				sb.WriteString(pad(t.Minute(), min(n, 2)))
+			case 's':
+				sb.WriteString(pad(t.Second(), min(n, 2)))
+			}
+		case fmtElapsed:
+			switch tok.text[0] {
+			case 'h':
+				sb.WriteString(pad(int(totalSeconds/3600), n))
+			case 'm':
+				sb.WriteString(pad(int(totalSeconds/60), n))
+			case 's':
+				sb.WriteString(pad(int(totalSeconds), n))
+			}
+		case fmtAmPm:
+			am := t.Hour() < 12
+			switch {
+			case tok.text == "A/P" && am:
+				sb.WriteString("A")
+			case tok.text == "A/P":
+				sb.WriteString("P")
+			case tok.text == "上午/下午" && am:
+				sb.WriteString("上午")
+			case tok.text == "上午/下午":
+				sb.WriteString("下午")
+			case am:
+				sb.WriteString("AM")
+			default:
+				sb.WriteString("PM")
+			}
+		case fmtPoint:
+			if precision > 0 && i+1 < len(s.tokens) && s.tokens[i+1].kind == fmtDigit {
+				sb.WriteString(".")
+				sb.WriteString(pad(fraction, precision))
+				i += precision
+			} else {
+				sb.WriteString(".")
+			}
+		case fmtGeneral, fmtText:
+		default:
+			sb.WriteString(tok.text)
+		}
+	}
+	return sb.String()
+}
+
+func pad(value, width int) string {
+	s := strconv.Itoa(value)
+	if len(s) < width {
+		s = strings.Repeat("0", width-len(s)) + s
+	}
+	return s
+}
@@ -0,0 +1,120 @@
+package xls
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatNumber(t *testing.T) {
+	cases := []struct {
+		value float64
+		code  string
+		want  string
+	}{
+		{1234.5, "General", "1234.5"},
+		{0.1 + 0.2, "General", "0.3"},
+		{1234.567, "0.00", "1234.57"},
+		{1234567.891, "#,##0.00", "1,234,567.89"},
+		{-1234.5, "#,##0_);(#,##0)", "(1,235)"},
+		{-5, `0;[Red]0`, "5"},
+		{0, `0.0;-0.0;"zero"`, "zero"},
+		{0.256, "0.0%", "25.6%"},
+		{12345, "0.00E+00", "1.23E+04"},
+		{1.25, "# ?/?", "1 1/4"},
+		{1234567, `#,##0,"K"`, "1,235K"},
+		{42, `"$"#,##0.00`, "$42.00"},
+		{150, `[<=100]0;"big"`, "big"},
+		{45000, "m/d/yyyy", "3/15/2023"},
+		{45000, `[$-409]dddd\, mmmm dd\, yyyy`, "Wednesday, March 15, 2023"},
+		{45000.75, "h:mm AM/PM", "6:00 PM"},
+		{45000.75, `上午/下午h"时"mm"分"`, "下午6时00分"},
+		{1.5, "[h]:mm:ss", "36:00:00"},
+		{0.000694, "mm:ss.0", "01:00.0"},
+		{59, "yyyy-mm-dd", "1900-02-28"},
+		{61, "d-mmm-yy", "1-Mar-00"},
+	}
+	for _, c := range cases {
+		if got := formatNumber(c.value, c.code, false); got != c.want {
+			t.Errorf("formatNumber(%v, %q) = %q, want %q", c.value, c.code, got, c.want)
+		}
+	}
+
+	if got := formatNumber(0, "yyyy-mm-dd", true); got != "1904-01-01" {
+		t.Errorf("1904 date system: got %q", got)
+	}
+}
+
+func TestFormatText(t *testing.T) {
+	cases := []struct {
+		text, code, want string
+	}{
+		{"abc", "General", "abc"},
+		{"abc", `"Name: "@`, "Name: abc"},
+		{"abc", `0;-0;0;"<"@">"`, "<abc>"},
+		{"abc", "0.00", "abc"},
+	}
+	for _, c := range cases {
+		if got := formatText(c.text, c.code); got != c.want {
+			t.Errorf("formatText(%q, %q) = %q, want %q", c.text, c.code, got, c.want)
+		}
+	}
+}
+
+func TestIsDateFormat(t *testing.T) {
+	for id := range builtInFormats {
+		date := id >= 14 && id <= 22 || id >= 27 && id <= 36 || id >= 45 && id <= 47 || id >= 50 && id <= 58
+		if got := isDateFormat(builtInFormats[id]); got != date {
+			t.Errorf("built-in format %d %q: got %v, want %v", id, builtInFormats[id], got, date)
+		}
+	}
+
+	cases := map[string]bool{
+		`[Red]0.00`:              false,
+		`0.00 "days"`:            false,
+		`#,##0 "h"`:              false,
+		`\d0`:                    false,
+		`[$-409]mmmm d, yyyy`:    true,
+		`[h]:mm`:                 true,
+		`[Blue][<=100]0;yyyy`:    false,
+		`yyyy"年"m"月"d"日"`:        true,
+		`_-* #,##0_-;-* #,##0_-`: false,
+	}
+	for code, want := range cases {
+		if got := isDateFormat(code); got != want {
+			t.Errorf("isDateFormat(%q) = %v, want %v", code, got, want)
+		}
+	}
+}
+
+func TestCellTime(t *testing.T) {
+	xf := func(format int) record {
+		return rec(XLS_TYPE_XF, uint16(0), uint16(format), uint16(0xFFF5), make([]byte, 14))
+	}
+	book := &testBook{
+		globals: []record{
+			rec(XLS_TYPE_FORMAT, uint16(164), xlString(`0.00 "days"`)),
+			xf(0), xf(14), xf(31), xf(164), xf(22),
+		},
+		sheets: []testSheet{{name: "S", records: []record{
+			rec(XLS_TYPE_NUMBER, 0, 0, 0, 45000.0),
+			rec(XLS_TYPE_NUMBER, 0, 1, 1, 45000.0),
+			rec(XLS_TYPE_NUMBER, 0, 2, 2, 45000.0),
+			rec(XLS_TYPE_NUMBER, 0, 3, 3, 45000.0),
+			rec(XLS_TYPE_NUMBER, 0, 4, 4, 45000.75),
+		}}},
+	}
+	row := openTestBook(t, book).Sheets()[0].Row(0)
+	want := []string{"", "2023-03-15 00:00:00", "2023-03-15 00:00:00", "", "2023-03-15 18:00:00"}
+	for col, w := range want {
+		got := ""
+		if tm, ok := row.Cell(col).Time(); ok {
+			got = tm.Format(time.DateTime)
+		}
+		if got != w {
+			t.Errorf("cell %d with format %q: got %q, want %q", col, row.Cell(col).NumberFormat(), got, w)
+		}
+	}
+	if got := row.Cell(2).FormattedValue(); got != "2023年3月15日" {
+		t.Errorf("format 31: got %q", got)
+	}
+}
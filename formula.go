@@ -0,0 +1,729 @@
+package xls
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Token identifiers of the BIFF8 parsed expression (rgce) of a formula.
+// Operand tokens exist in reference, value and array classes (0x2?, 0x4? and 0x6?),
+// parseFormula folds them all into the reference class listed here.
+const (
+	ptgExp       = 0x01
+	ptgTbl       = 0x02
+	ptgAdd       = 0x03
+	ptgSub       = 0x04
+	ptgMul       = 0x05
+	ptgDiv       = 0x06
+	ptgPower     = 0x07
+	ptgConcat    = 0x08
+	ptgLT        = 0x09
+	ptgLE        = 0x0a
+	ptgEQ        = 0x0b
+	ptgGE        = 0x0c
+	ptgGT        = 0x0d
+	ptgNE        = 0x0e
+	ptgIsect     = 0x0f
+	ptgUnion     = 0x10
+	ptgRange     = 0x11
+	ptgUplus     = 0x12
+	ptgUminus    = 0x13
+	ptgPercent   = 0x14
+	ptgParen     = 0x15
+	ptgMissArg   = 0x16
+	ptgStr       = 0x17
+	ptgAttr      = 0x19
+	ptgErr       = 0x1c
+	ptgBool      = 0x1d
+	ptgInt       = 0x1e
+	ptgNum       = 0x1f
+	ptgArray     = 0x20
+	ptgFunc      = 0x21
+	ptgFuncVar   = 0x22
+	ptgName      = 0x23
+	ptgRef       = 0x24
+	ptgArea      = 0x25
+	ptgMemArea   = 0x26
+	ptgMemErr    = 0x27
+	ptgMemNoMem  = 0x28
+	ptgMemFunc   = 0x29
+	ptgRefErr    = 0x2a
+	ptgAreaErr   = 0x2b
+	ptgRefN      = 0x2c
+	ptgAreaN     = 0x2d
+	ptgNameX     = 0x39
+	ptgRef3d     = 0x3a
+	ptgArea3d    = 0x3b
+	ptgRefErr3d  = 0x3c
+	ptgAreaErr3d = 0x3d
+)
+
+// Option flags of tAttr tokens.
+const (
+	ptgAttrVolatile = 0x01
+	ptgAttrIf       = 0x02
+	ptgAttrChoose   = 0x04
+	ptgAttrSkip     = 0x08
+	ptgAttrSum      = 0x10
+	ptgAttrSpace    = 0x40
+)
+
+// errUnsupportedFormula is returned for token streams this package cannot decode,
+// e.g. formulas of BIFF5 files or macro sheet commands.
+var errUnsupportedFormula = errors.New("unsupported formula token")
+
+var operators = map[byte]string{
+	ptgAdd:     "+",
+	ptgSub:     "-",
+	ptgMul:     "*",
+	ptgDiv:     "/",
+	ptgPower:   "^",
+	ptgConcat:  "&",
+	ptgLT:      "<",
+	ptgLE:      "<=",
+	ptgEQ:      "=",
+	ptgGE:      ">=",
+	ptgGT:      ">",
+	ptgNE:      "<>",
+	ptgIsect:   " ",
+	ptgUnion:   ",",
+	ptgRange:   ":",
+	ptgUplus:   "+",
+	ptgUminus:  "-",
+	ptgPercent: "%",
+}
+
+// ptgSizes lists the size of tokens longer than their identifier byte,
+// variable sized tokens list their fixed part.
+var ptgSizes = map[byte]int{
+	ptgExp: 5, ptgTbl: 5, ptgAttr: 4, ptgErr: 2, ptgBool: 2, ptgInt: 3, ptgNum: 9,
+	ptgArray: 8, ptgFunc: 3, ptgFuncVar: 4, ptgName: 5, ptgRef: 5, ptgArea: 9,
+	ptgMemArea: 7, ptgMemErr: 7, ptgMemNoMem: 7, ptgMemFunc: 3, ptgRefErr: 5, ptgAreaErr: 9,
+	ptgRefN: 5, ptgAreaN: 9, ptgNameX: 7, ptgRef3d: 7, ptgArea3d: 11, ptgRefErr3d: 7, ptgAreaErr3d: 11,
+}
+
+// ptg is a single token of a parsed expression.
+type ptg struct {
+	id byte
+
+	// text is the rendered operand, operator symbol or function name;
+	// for tAttr space tokens it holds the whitespace itself
+	text string
+
+	// argc is the number of arguments of function tokens
+	argc int
+
+	// attr holds the option flags of tAttr tokens, space is the kind of whitespace of tAttrSpace
+	attr  byte
+	space byte
+
+	// row and col locate the master cell of tExp tokens
+	row int
+	col int
+
+	// sub holds the sub-expression of tMem* tokens
+	sub []ptg
+
+	// class is the operand class of operand tokens: ptgClassRef, ptgClassValue or ptgClassArray
+	class byte
+
+	// value is the constant of tStr, tErr, tBool, tInt, tNum and tArray tokens,
+	// area the cells referred to by reference tokens
+	value interface{}
+	area  *area
+
+	// index is the zero-based index of the defined name of tName and internal tNameX tokens, or -1
+	index int
+}
+
+// Operand classes, the bits 0x60 of the token identifier.
+const (
+	ptgClassRef   = 0x20
+	ptgClassValue = 0x40
+	ptgClassArray = 0x60
+)
+
+// area is the block of cells a reference token refers to.
+type area struct {
+	// firstSheet and lastSheet index the sheets of the workbook, -1 stands for the sheet of the formula
+	firstSheet int
+	lastSheet  int
+
+	rng Range
+}
+
+// sharedFormula is the formula of a SHAREDFMLA or ARRAY record, referenced by the tExp tokens of its member cells.
+type sharedFormula struct {
+	rng   Range
+	array bool
+
+	// rgce is the parsed expression, extra the additional data following it
+	rgce  []byte
+	extra []byte
+}
+
+// expCell is a formula cell consisting of a tExp token, resolved once the sheet has been read.
+type expCell struct {
+	cell     *Cell
+	row, col int
+}
+
+// parseFormula splits the parsed expression rgce into tokens and resolves references, names and sheets to text.
+// extra is the additional data following rgce (array constants), row and col locate the cell
+// relative references (tRefN, tAreaN) are based on.
+func (xls *XLS) parseFormula(rgce, extra []byte, row, col int) ([]ptg, error) {
+	if xls.version != XLS_BIFF8 {
+		return nil, errUnsupportedFormula
+	}
+
+	p := &formulaParser{xls: xls, extra: extra, row: row, col: col}
+	return p.parse(rgce)
+}
+
+type formulaParser struct {
+	xls *XLS
+
+	// array constants of tArray tokens, consumed in token order
+	extra []byte
+
+	row int
+	col int
+}
+
+func (p *formulaParser) parse(rgce []byte) ([]ptg, error) {
+	var tokens []ptg
+
+	pos := 0
+	for pos < len(rgce) {
+		token, size, err := p.next(rgce[pos:])
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+		pos += size
+	}
+
+	return tokens, nil
+}
+
+// next reads the token at the start of data and returns it with its size in bytes.
+func (p *formulaParser) next(data []byte) (ptg, int, error) {
+	id := data[0]
+	var class byte
+	if id >= 0x20 && id < 0x80 {
+		class = id & 0x60
+		id = (id & 0x1f) | 0x20
+	}
+
+	token := ptg{id: id, class: class, index: -1}
+
+	size, ok := ptgSizes[id]
+	if !ok {
+		size = 1
+	}
+	if size > len(data) {
+		return token, 0, ErrTruncated
+	}
+
+	switch id {
+	case ptgExp, ptgTbl:
+		// offset: 1; size: 2; row of the master cell; offset: 3; size: 2; column of the master cell
+		token.row = int(getUInt2d(data, 1))
+		token.col = int(getUInt2d(data, 3))
+
+	case ptgAdd, ptgSub, ptgMul, ptgDiv, ptgPower, ptgConcat, ptgLT, ptgLE, ptgEQ, ptgGE, ptgGT, ptgNE,
+		ptgIsect, ptgUnion, ptgRange, ptgUplus, ptgUminus, ptgPercent:
+		token.text = operators[id]
+
+	case ptgParen, ptgMissArg:
+
+	case ptgStr:
+		// offset: 1; size: var; Unicode string, 8-bit string length
+		stringData, err := p.xls.readUnicodeStringShort(data[1:])
+		if err != nil {
+			return token, 0, err
+		}
+		token.text = `"` + strings.ReplaceAll(stringData.value, `"`, `""`) + `"`
+		token.value = stringData.value
+		size = 1 + stringData.size
+
+	case ptgAttr:
+		// offset: 1; size: 1; option flags
+		token.attr = data[1]
+		switch {
+		case token.attr&ptgAttrSpace != 0:
+			// offset: 2; size: 1; kind of whitespace; offset: 3; size: 1; number of characters
+			token.space = data[2]
+			char := " "
+			if token.space%2 == 1 {
+				// odd kinds are carriage returns
+				char = "\n"
+			}
+			token.text = strings.Repeat(char, int(data[3]))
+		case token.attr&ptgAttrChoose != 0:
+			// offset: 2; size: 2; number of choices, followed by a jump table of choices + 1 offsets
+			size = 4 + 2*(int(getUInt2d(data, 2))+1)
+			if size > len(data) {
+				return token, 0, ErrTruncated
+			}
+		}
+
+	case ptgErr:
+		token.text = mapErrorCode(data[1])
+		token.value = formulaError(token.text)
+
+	case ptgBool:
+		token.text = "FALSE"
+		if data[1] != 0 {
+			token.text = "TRUE"
+		}
+		token.value = data[1] != 0
+
+	case ptgInt:
+		token.text = strconv.Itoa(int(getUInt2d(data, 1)))
+		token.value = float64(getUInt2d(data, 1))
+
+	case ptgNum:
+		token.value = extractNumber(data[1:9])
+		token.text = formatFormulaNumber(token.value.(float64))
+
+	case ptgArray:
+		text, values, err := p.readArray()
+		if err != nil {
+			return token, 0, err
+		}
+		token.text = text
+		token.value = values
+
+	case ptgFunc:
+		// offset: 1; size: 2; index to the built-in function
+		fn, ok := functions[getUInt2d(data, 1)]
+		if !ok || fn.argc < 0 {
+			return token, 0, errUnsupportedFormula
+		}
+		token.text = fn.name
+		token.argc = fn.argc
+
+	case ptgFuncVar:
+		// offset: 1; size: 1; number of arguments
+		token.argc = int(data[1] & 0x7f)
+		// offset: 2; size: 2; bit 0-14: index to the built-in function; bit 15: macro command
+		index := getUInt2d(data, 2) & 0x7fff
+		if index != functionUserDefined {
+			fn, ok := functions[index]
+			if !ok {
+				return token, 0, errUnsupportedFormula
+			}
+			token.text = fn.name
+		}
+
+	case ptgName:
+		// offset: 1; size: 2; one-based index to DEFINEDNAME record
+		index := int(getUInt2d(data, 1)) - 1
+		if index < 0 || index >= len(p.xls.definedNames) {
+			token.text = mapErrorCode(0x1D)
+		} else {
+			token.text = p.xls.definedNames[index].name
+			token.index = index
+		}
+
+	case ptgRef, ptgRefN:
+		// offset: 1; size: 4; cell address
+		token.text = p.cellAddress(data[1:5], id == ptgRefN)
+		token.area = p.readArea(data[1:3], data[1:3], data[3:5], data[3:5], id == ptgRefN)
+
+	case ptgArea, ptgAreaN:
+		// offset: 1; size: 8; cell range address
+		token.text = p.rangeAddress(data[1:9], id == ptgAreaN)
+		token.area = p.readArea(data[1:3], data[3:5], data[5:7], data[7:9], id == ptgAreaN)
+
+	case ptgMemArea, ptgMemErr, ptgMemNoMem, ptgMemFunc:
+		// a sub-expression with precomputed data, the tokens of the sub-expression follow
+		offset := 5
+		if id == ptgMemFunc {
+			offset = 1
+		}
+		subSize := int(getUInt2d(data, offset))
+		size = offset + 2 + subSize
+		if size > len(data) {
+			return token, 0, ErrTruncated
+		}
+		if id == ptgMemArea {
+			// the extra data holds the rectangles of the precomputed area, before the array constants
+			// of the sub-expression: offset: 0; size: 2; count; offset: 2; size: 8 * count; cell ranges
+			if len(p.extra) < 2 || 2+8*int(getUInt2d(p.extra, 0)) > len(p.extra) {
+				return token, 0, ErrTruncated
+			}
+			p.extra = p.extra[2+8*int(getUInt2d(p.extra, 0)):]
+		}
+		sub, err := p.parse(data[offset+2 : size])
+		if err != nil {
+			return token, 0, err
+		}
+		token.sub = sub
+		text, err := decompileFormula(sub)
+		if err != nil {
+			return token, 0, err
+		}
+		token.text = text
+
+	case ptgRefErr, ptgAreaErr:
+		token.text = mapErrorCode(0x17)
+		token.value = formulaError(token.text)
+
+	case ptgNameX:
+		// offset: 1; size: 2; index to EXTERNSHEET; offset: 3; size: 2; one-based index to the name
+		ixti, nameIndex := int(getUInt2d(data, 1)), int(getUInt2d(data, 3))-1
+		token.text = p.xls.externalName(ixti, nameIndex)
+		if p.xls.isInternalRef(ixti) && nameIndex >= 0 && nameIndex < len(p.xls.definedNames) {
+			token.index = nameIndex
+		}
+
+	case ptgRef3d, ptgArea3d:
+		// offset: 1; size: 2; index to EXTERNSHEET
+		ixti := int(getUInt2d(data, 1))
+		prefix, ok := p.xls.externSheetPrefix(ixti)
+		if !ok {
+			token.text = mapErrorCode(0x17)
+			token.value = formulaError(token.text)
+			break
+		}
+		if id == ptgRef3d {
+			token.text = prefix + p.cellAddress(data[3:7], false)
+			token.area = p.readArea(data[3:5], data[3:5], data[5:7], data[5:7], false)
+		} else {
+			token.text = prefix + p.rangeAddress(data[3:11], false)
+			token.area = p.readArea(data[3:5], data[5:7], data[7:9], data[9:11], false)
+		}
+		if p.xls.isInternalRef(ixti) {
+			ref := p.xls.externSheets[ixti]
+			token.area.firstSheet, token.area.lastSheet = ref.firstSheet, ref.lastSheet
+		} else {
+			// cells of other workbooks cannot be evaluated
+			token.area = nil
+			token.value = formulaError(mapErrorCode(0x17))
+		}
+
+	case ptgRefErr3d, ptgAreaErr3d:
+		token.text = mapErrorCode(0x17)
+		token.value = formulaError(token.text)
+
+	default:
+		return token, 0, errUnsupportedFormula
+	}
+
+	return token, size, nil
+}
+
+// cellAddress renders a 4 byte cell address as e.g. $A1.
+func (p *formulaParser) cellAddress(data []byte, offsets bool) string {
+	row, col, rowRelative, colRelative := p.readCellAddress(data, offsets)
+	return formatCellAddress(row, col, rowRelative, colRelative)
+}
+
+// readCellAddress decodes a 4 byte cell address into zero-based coordinates and relative flags.
+// Relative parts of tRefN and tAreaN tokens are offsets to the cell the formula belongs to.
+func (p *formulaParser) readCellAddress(data []byte, offsets bool) (int, int, bool, bool) {
+	// offset: 0; size: 2; index to row
+	row := int(getUInt2d(data, 0))
+	// offset: 2; size: 2; index to column and relative flags
+	colData := getUInt2d(data, 2)
+
+	// bit: 15; mask: 0x8000; 1 = relative row index
+	rowRelative := colData&0x8000 != 0
+	// bit: 14; mask: 0x4000; 1 = relative column index
+	colRelative := colData&0x4000 != 0
+
+	col := int(colData & 0x3fff)
+	if offsets {
+		if rowRelative {
+			row = (p.row + int(int16(row))) & 0xffff
+		}
+		if colRelative {
+			col = (p.col + int(int8(colData&0xff))) & 0xff
+		}
+	}
+
+	return row, col, rowRelative, colRelative
+}
+
+// readArea decodes the rows and columns of a reference token into an area on the sheet of the formula.
+func (p *formulaParser) readArea(row1, row2, col1, col2 []byte, offsets bool) *area {
+	firstRow, firstCol, _, _ := p.readCellAddress(append(append([]byte(nil), row1...), col1...), offsets)
+	lastRow, lastCol, _, _ := p.readCellAddress(append(append([]byte(nil), row2...), col2...), offsets)
+
+	return &area{
+		firstSheet: -1,
+		lastSheet:  -1,
+		rng: Range{
+			FirstRow: min(firstRow, lastRow),
+			LastRow:  max(firstRow, lastRow),
+			FirstCol: min(firstCol, lastCol),
+			LastCol:  max(firstCol, lastCol),
+		},
+	}
+}
+
+// rangeAddress renders an 8 byte cell range address as e.g. A1:$B$2, A:A for entire columns or 1:1 for entire rows.
+func (p *formulaParser) rangeAddress(data []byte, offsets bool) string {
+	// offset: 0; size: 2; index to first row; offset: 2; size: 2; index to last row
+	// offset: 4; size: 2; index to first column; offset: 6; size: 2; index to last column
+	row1, col1, rowRelative1, colRelative1 := p.readCellAddress([]byte{data[0], data[1], data[4], data[5]}, offsets)
+	row2, col2, rowRelative2, colRelative2 := p.readCellAddress([]byte{data[2], data[3], data[6], data[7]}, offsets)
+
+	if !offsets && row1 == 0 && row2 == 0xffff {
+		return formatColumn(col1, colRelative1) + ":" + formatColumn(col2, colRelative2)
+	}
+	if !offsets && col1 == 0 && col2 == 0xff {
+		return formatRow(row1, rowRelative1) + ":" + formatRow(row2, rowRelative2)
+	}
+
+	return formatCellAddress(row1, col1, rowRelative1, colRelative1) + ":" + formatCellAddress(row2, col2, rowRelative2, colRelative2)
+}
+
+// readArray reads the next array constant stored after the token stream.
+// It returns the constant rendered as e.g. {1,2;"a",TRUE} and its values by row.
+func (p *formulaParser) readArray() (string, [][]interface{}, error) {
+	data := p.extra
+	if len(data) < 3 {
+		return "", nil, ErrTruncated
+	}
+
+	// offset: 0; size: 1; number of columns - 1
+	cols := int(data[0]) + 1
+	// offset: 1; size: 2; number of rows - 1
+	rows := int(getUInt2d(data, 1)) + 1
+	pos := 3
+
+	values := make([][]interface{}, rows)
+
+	var sb strings.Builder
+	sb.WriteString("{")
+	for r := 0; r < rows; r++ {
+		if r > 0 {
+			sb.WriteString(";")
+		}
+		values[r] = make([]interface{}, cols)
+		for c := 0; c < cols; c++ {
+			if c > 0 {
+				sb.WriteString(",")
+			}
+			if pos >= len(data) {
+				return "", nil, ErrTruncated
+			}
+			// offset: 0; size: 1; type of the constant value
+			switch data[pos] {
+			case 0x00: // empty
+				pos += 9
+			case 0x01: // number
+				if pos+9 > len(data) {
+					return "", nil, ErrTruncated
+				}
+				value := extractNumber(data[pos+1 : pos+9])
+				sb.WriteString(formatFormulaNumber(value))
+				values[r][c] = value
+				pos += 9
+			case 0x02: // string
+				stringData, err := p.xls.readUnicodeStringLong(data[pos+1:])
+				if err != nil {
+					return "", nil, err
+				}
+				sb.WriteString(`"` + strings.ReplaceAll(stringData.value, `"`, `""`) + `"`)
+				values[r][c] = stringData.value
+				pos += 1 + stringData.size
+			case 0x04: // boolean
+				if pos+2 > len(data) {
+					return "", nil, ErrTruncated
+				}
+				if data[pos+1] != 0 {
+					sb.WriteString("TRUE")
+				} else {
+					sb.WriteString("FALSE")
+				}
+				values[r][c] = data[pos+1] != 0
+				pos += 9
+			case 0x10: // error
+				if pos+2 > len(data) {
+					return "", nil, ErrTruncated
+				}
+				sb.WriteString(mapErrorCode(data[pos+1]))
+				values[r][c] = formulaError(mapErrorCode(data[pos+1]))
+				pos += 9
+			default:
+				return "", nil, ErrCorrupt
+			}
+		}
+	}
+	sb.WriteString("}")
+
+	p.extra = data[min(pos, len(data)):]
+	return sb.String(), values, nil
+}
+
+// decompileFormula renders the tokens of a parsed expression as formula text without the leading "=".
+func decompileFormula(tokens []ptg) (string, error) {
+	var stack []string
+
+	pop := func() (string, error) {
+		if len(stack) == 0 {
+			return "", ErrCorrupt
+		}
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		return s, nil
+	}
+
+	// whitespace of tAttrSpace tokens, indexed by kind, applied to the next token
+	var spaces [7]string
+
+	for _, token := range tokens {
+		// spaces (0) and carriage returns (1) before the token
+		before := spaces[1] + spaces[0]
+
+		switch token.id {
+		case ptgAttr:
+			if token.attr&ptgAttrSpace != 0 {
+				if int(token.space) < len(spaces) {
+					spaces[token.space] += token.text
+				}
+				continue
+			}
+			if token.attr&ptgAttrSum != 0 {
+				// SUM with a single argument
+				op, err := pop()
+				if err != nil {
+					return "", err
+				}
+				stack = append(stack, before+"SUM("+op+")")
+			} else {
+				// IF, CHOOSE and skip jumps, volatile flag: no effect on the text
+				continue
+			}
+
+		case ptgAdd, ptgSub, ptgMul, ptgDiv, ptgPower, ptgConcat, ptgLT, ptgLE, ptgEQ, ptgGE, ptgGT, ptgNE,
+			ptgIsect, ptgUnion, ptgRange:
+			op2, err := pop()
+			if err != nil {
+				return "", err
+			}
+			op1, err := pop()
+			if err != nil {
+				return "", err
+			}
+			stack = append(stack, op1+before+token.text+op2)
+
+		case ptgUplus, ptgUminus:
+			op, err := pop()
+			if err != nil {
+				return "", err
+			}
+			stack = append(stack, before+token.text+op)
+
+		case ptgPercent:
+			op, err := pop()
+			if err != nil {
+				return "", err
+			}
+			stack = append(stack, op+before+token.text)
+
+		case ptgParen:
+			op, err := pop()
+			if err != nil {
+				return "", err
+			}
+			// spaces and carriage returns before the opening (2, 3) and closing (4, 5) parenthesis
+			stack = append(stack, spaces[3]+spaces[2]+"("+op+spaces[5]+spaces[4]+")")
+
+		case ptgFunc, ptgFuncVar:
+			if token.argc > len(stack) {
+				return "", ErrCorrupt
+			}
+			args := append([]string(nil), stack[len(stack)-token.argc:]...)
+			stack = stack[:len(stack)-token.argc]
+
+			name := token.text
+			if token.id == ptgFuncVar && name == "" {
+				// add-in or macro function, the name is the first argument
+				if len(args) == 0 {
+					return "", ErrCorrupt
+				}
+				name, args = args[0], args[1:]
+			}
+			stack = append(stack, before+name+"("+strings.Join(args, ",")+")")
+
+		case ptgExp, ptgTbl:
+			// shared and array formulas are resolved before decompiling
+			return "", errUnsupportedFormula
+
+		default:
+			stack = append(stack, before+token.text)
+		}
+
+		spaces = [7]string{}
+	}
+
+	if len(stack) != 1 {
+		return "", ErrCorrupt
+	}
+
+	return stack[0], nil
+}
+
+// formatCellAddress renders zero-based coordinates in A1 notation, absolute parts prefixed by $.
+func formatCellAddress(row, col int, rowRelative, colRelative bool) string {
+	return formatColumn(col, colRelative) + formatRow(row, rowRelative)
+}
+
+func formatColumn(col int, relative bool) string {
+	if relative {
+		return columnName(col)
+	}
+	return "$" + columnName(col)
+}
+
+func formatRow(row int, relative bool) string {
+	if relative {
+		return strconv.Itoa(row + 1)
+	}
+	return "$" + strconv.Itoa(row+1)
+}
+
+// formatFormulaNumber renders a numeric constant the way Excel displays it in the formula bar.
+func formatFormulaNumber(value float64) string {
+	abs := math.Abs(value)
+	if abs != 0 && (abs >= 1e15 || abs < 1e-9) {
+		return strings.ToUpper(strconv.FormatFloat(value, 'E', -1, 64))
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// quoteSheetName quotes a sheet name for use in a reference when it is not a plain identifier.
+func quoteSheetName(name string) string {
+	plain := name != ""
+	for i, r := range name {
+		if !(unicode.IsLetter(r) || r == '_' || (i > 0 && (unicode.IsDigit(r) || r == '.'))) {
+			plain = false
+			break
+		}
+	}
+	if plain && looksLikeCellAddress(name) {
+		plain = false
+	}
+	if plain {
+		return name
+	}
+	return "'" + strings.ReplaceAll(name, "'", "''") + "'"
+}
+
+// looksLikeCellAddress reports whether a name could be read as an A1 or R1C1 reference, e.g. "AB12" or "R2C3".
+func looksLikeCellAddress(name string) bool {
+	upper := strings.ToUpper(name)
+	letters := strings.TrimRight(upper, "0123456789")
+	if letters != upper && len(letters) > 0 && len(letters) <= 3 && strings.Trim(letters, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") == "" {
+		return true
+	}
+	return strings.HasPrefix(upper, "R") && strings.Trim(upper, "RC0123456789") == ""
+}
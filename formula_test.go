@@ -0,0 +1,163 @@
+package xls
+
+import (
+	"errors"
+	"testing"
+)
+
+// formulaBook has the sheets S1, Sheet2, My Sheet and Other, an external workbook, the add-in function
+// EOMONTH and the built-in name Print_Area, for the references of formulas.
+func formulaBook(records []record) *testBook {
+	return &testBook{
+		globals: []record{
+			// the workbook itself, ext.xls with the sheet Ext, add-in functions
+			rec(XLS_TYPE_EXTERNALBOOK, uint16(4), uint8(1), uint8(4)),
+			rec(XLS_TYPE_EXTERNALBOOK, uint16(1), wideString("\x01ext.xls"), xlString("Ext")),
+			rec(XLS_TYPE_EXTERNALBOOK, uint16(1), uint8(1), uint8(0x3A)),
+			rec(XLS_TYPE_EXTERNNAME, uint16(0), uint32(0), shortString("EOMONTH"), uint16(2), uint8(ptgErr), uint8(0x17)),
+			// Sheet2, Sheet2:My Sheet, [1]Ext, add-in functions
+			rec(XLS_TYPE_EXTERNSHEET, uint16(4), uint16(0), uint16(1), uint16(1), uint16(0), uint16(1), uint16(2),
+				uint16(1), uint16(0), uint16(0), uint16(2), uint16(0xFFFE), uint16(0xFFFE)),
+			// Print_Area of S1
+			rec(XLS_TYPE_DEFINEDNAME, uint16(0x20), uint8(0), uint8(1), uint16(0), uint16(0), uint16(1), uint32(0), uint8(0), uint8(6)),
+		},
+		sheets: []testSheet{{name: "S1", records: records}, {name: "Sheet2"}, {name: "My Sheet"}, {name: "Other"}},
+	}
+}
+
+func TestFormulaText(t *testing.T) {
+	cases := []struct {
+		name        string
+		rgce, extra []byte
+		want        string
+	}{
+		{
+			"attr sum and 3D reference",
+			tokens(tArea(1, 9, 1, 1), pack(uint8(ptgAttr), uint8(ptgAttrSum), uint16(0)),
+				pack(uint8(ptgRef3d|0x20), uint16(0), uint16(2), uint16(2|0xC000)), pack(uint8(ptgMul))),
+			nil, "=SUM(B2:B10)*Sheet2!C3",
+		},
+		{
+			"IF with quoted strings",
+			tokens(tRef(0, 0), tInt(3), pack(uint8(ptgGT)), tStr("yes"), tStr(`n"o`), tFuncVar(3, 1)),
+			nil, `=IF(A1>3,"yes","n""o")`,
+		},
+		{
+			"spaces, parentheses and unary minus",
+			tokens(pack(uint8(ptgRef|0x20), uint16(0), uint16(0)), pack(uint8(ptgAttr), uint8(ptgAttrSpace), uint8(0), uint8(1)),
+				pack(uint8(ptgNum), 1.0), pack(uint8(ptgAttr), uint8(ptgAttrSpace), uint8(0), uint8(1)),
+				pack(uint8(ptgAdd), uint8(ptgParen), uint8(ptgUminus))),
+			nil, "=-($A$1 + 1)",
+		},
+		{
+			"array constant",
+			pack(uint8(ptgArray|0x40), make([]byte, 7)),
+			pack(uint8(1), uint16(1), uint8(1), 1.0, uint8(2), xlString("a"), uint8(4), uint8(1), make([]byte, 7),
+				uint8(0x10), uint8(0x07), make([]byte, 7)),
+			`={1,"a";TRUE,#DIV/0!}`,
+		},
+		{
+			"array constant after tMemArea",
+			tokens(pack(uint8(ptgMemArea|0x20), uint32(0), uint16(9)), tArea(0, 1, 0, 1),
+				pack(uint8(ptgArray|0x40), make([]byte, 7)), pack(uint8(ptgAdd))),
+			pack(uint16(1), uint16(0), uint16(1), uint16(0), uint16(1),
+				uint8(1), uint16(0), uint8(1), 1.0, uint8(1), 2.0),
+			"=A1:B2+{1,2}",
+		},
+		{
+			"3D area over a sheet range",
+			pack(uint8(ptgArea3d|0x20), uint16(1), uint16(0), uint16(0xFFFF), uint16(0xC000), uint16(0xC001)),
+			nil, "='Sheet2:My Sheet'!A:B",
+		},
+		{
+			"defined name and percent",
+			tokens(pack(uint8(ptgName|0x20), uint16(1), uint16(0)), tInt(50), pack(uint8(ptgPercent), uint8(ptgMul))),
+			nil, "=Print_Area*50%",
+		},
+		{
+			"external reference",
+			pack(uint8(ptgRef3d|0x20), uint16(2), uint16(0), uint16(0xC000)),
+			nil, "=[1]Ext!A1",
+		},
+		{
+			"add-in function",
+			tokens(pack(uint8(ptgNameX|0x20), uint16(3), uint16(1), uint16(0)), tRef(0, 0), tFuncVar(2, functionUserDefined)),
+			nil, "=EOMONTH(A1)",
+		},
+	}
+
+	var records []record
+	for i, c := range cases {
+		records = append(records, rec(XLS_TYPE_FORMULA, i, 0, 15, 0.0, uint16(0), uint32(0), uint16(len(c.rgce)), c.rgce, c.extra))
+	}
+	sheet := openTestBook(t, formulaBook(records)).Sheets()[0]
+	for i, c := range cases {
+		if got := sheet.Row(i).Cell(0).Formula(); got != c.want {
+			t.Errorf("%s: got %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestParseFormulaErrors(t *testing.T) {
+	xls := &XLS{version: XLS_BIFF8}
+	cases := []struct {
+		name string
+		rgce []byte
+		want error
+	}{
+		{"truncated reference", pack(uint8(ptgRef|0x20), uint16(0)), ErrTruncated},
+		{"truncated number", pack(uint8(ptgNum), uint32(0)), ErrTruncated},
+		{"string past the end", pack(uint8(ptgStr), uint8(10), uint8(0), "ab"), ErrTruncated},
+		{"sub-expression past the end", pack(uint8(ptgMemFunc|0x20), uint16(20), uint8(ptgInt)), ErrTruncated},
+		{"array without its data", pack(uint8(ptgArray|0x40), make([]byte, 7)), ErrTruncated},
+		{"unknown token", pack(uint8(0x1A)), errUnsupportedFormula},
+	}
+	for _, c := range cases {
+		if _, err := xls.parseFormula(c.rgce, nil, 0, 0); !errors.Is(err, c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, err, c.want)
+		}
+	}
+
+	biff5 := &XLS{version: XLS_BIFF7}
+	if _, err := biff5.parseFormula(tInt(1), nil, 0, 0); !errors.Is(err, errUnsupportedFormula) {
+		t.Errorf("BIFF5 formula: got %v", err)
+	}
+}
+
+func TestSharedFormulas(t *testing.T) {
+	tExp := func(row, col int) []byte { return pack(uint8(ptgExp), uint16(row), uint16(col)) }
+	// the cell to the left times 2
+	shared := tokens(pack(uint8(ptgRefN|0x20), uint16(0), uint16(0xFF|0xC000)), tInt(2), pack(uint8(ptgMul)))
+	array := tokens(tArea(0, 1, 0, 0), tInt(2), pack(uint8(ptgMul)))
+	book := &testBook{sheets: []testSheet{{name: "S", records: []record{
+		numberRec(0, 0, 1), numberRec(1, 0, 2), numberRec(2, 0, 3),
+		formulaRec(0, 1, 2, tExp(0, 1)),
+		rec(XLS_TYPE_SHAREDFMLA, uint16(0), uint16(2), uint8(1), uint8(1), uint8(0), uint8(3),
+			uint16(len(shared)), shared),
+		formulaRec(1, 1, 4, tExp(0, 1)),
+		formulaRec(2, 1, 6, tExp(0, 1)),
+		// refers to a shared formula it is not part of
+		formulaRec(3, 1, 0, tExp(0, 1)),
+		formulaRec(0, 3, 2, tExp(0, 3)),
+		rec(XLS_TYPE_ARRAY, uint16(0), uint16(1), uint8(3), uint8(3), uint16(0), uint32(0),
+			uint16(len(array)), array),
+		formulaRec(1, 3, 4, tExp(0, 3)),
+	}}}}
+	sheet := openTestBook(t, book).Sheets()[0]
+
+	for row, want := range []string{"=A1*2", "=A2*2", "=A3*2", ""} {
+		if got := sheet.Row(row).Cell(1).Formula(); got != want {
+			t.Errorf("B%d: got %q, want %q", row+1, got, want)
+		}
+	}
+	for row := 0; row < 2; row++ {
+		cell := sheet.Row(row).Cell(3)
+		rng, ok := cell.ArrayRange()
+		if got := cell.Formula(); got != "=A1:A2*2" || !ok || rng != (Range{0, 1, 3, 3}) {
+			t.Errorf("D%d: got %q, %v, %v", row+1, got, rng, ok)
+		}
+	}
+	if _, ok := sheet.Row(0).Cell(1).ArrayRange(); ok {
+		t.Error("B1 is part of an array formula")
+	}
+}
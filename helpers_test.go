@@ -0,0 +1,249 @@
+package xls
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"unicode/utf16"
+)
+
+// The tests build workbooks from hand-written BIFF records, wrapped in a minimal compound file.
+
+// record is a BIFF record without its header.
+type record struct {
+	typ  uint16
+	data []byte
+}
+
+// pack encodes the parts in little-endian order: integers by their size (int as 2 bytes),
+// float64 as IEEE 754, byte slices and strings as is.
+func pack(parts ...interface{}) []byte {
+	var buf bytes.Buffer
+	for _, part := range parts {
+		switch v := part.(type) {
+		case int:
+			binary.Write(&buf, binary.LittleEndian, uint16(v))
+		case float64:
+			binary.Write(&buf, binary.LittleEndian, math.Float64bits(v))
+		case []byte:
+			buf.Write(v)
+		case string:
+			buf.WriteString(v)
+		case uint8, uint16, int16, uint32, int32:
+			binary.Write(&buf, binary.LittleEndian, v)
+		default:
+			panic("pack: unsupported part")
+		}
+	}
+	return buf.Bytes()
+}
+
+func rec(typ uint16, parts ...interface{}) record {
+	return record{typ: typ, data: pack(parts...)}
+}
+
+// xlString is a compressed string with a 16-bit character count, shortString with an 8-bit count.
+func xlString(s string) []byte {
+	return pack(uint16(len(s)), uint8(0), s)
+}
+
+func shortString(s string) []byte {
+	return pack(uint8(len(s)), uint8(0), s)
+}
+
+// wideString is an uncompressed string with a 16-bit character count.
+func wideString(s string) []byte {
+	return pack(uint16(len(utf16.Encode([]rune(s)))), uint8(1), utf16Bytes(s))
+}
+
+func utf16Bytes(s string) []byte {
+	var b []byte
+	for _, c := range utf16.Encode([]rune(s)) {
+		b = append(b, byte(c), byte(c>>8))
+	}
+	return b
+}
+
+// cells
+
+func numberRec(row, col int, value float64) record {
+	return rec(XLS_TYPE_NUMBER, row, col, 15, value)
+}
+
+func labelRec(row, col int, s string) record {
+	return rec(XLS_TYPE_LABEL, row, col, 15, xlString(s))
+}
+
+// formulaRec is a FORMULA record with a numeric cached result.
+func formulaRec(row, col int, result float64, rgce []byte) record {
+	return rec(XLS_TYPE_FORMULA, row, col, 15, result, uint16(0), uint32(0), uint16(len(rgce)), rgce)
+}
+
+// formula tokens
+
+func tRef(row, col int) []byte {
+	return pack(uint8(ptgRef|0x20), uint16(row), uint16(col|0xC000))
+}
+
+func tArea(firstRow, lastRow, firstCol, lastCol int) []byte {
+	return pack(uint8(ptgArea|0x20), uint16(firstRow), uint16(lastRow), uint16(firstCol|0xC000), uint16(lastCol|0xC000))
+}
+
+func tInt(v int) []byte {
+	return pack(uint8(ptgInt), uint16(v))
+}
+
+func tStr(s string) []byte {
+	return pack(uint8(ptgStr), shortString(s))
+}
+
+func tBool(b bool) []byte {
+	if b {
+		return pack(uint8(ptgBool), uint8(1))
+	}
+	return pack(uint8(ptgBool), uint8(0))
+}
+
+func tFunc(iftab int) []byte {
+	return pack(uint8(ptgFunc|0x20), uint16(iftab))
+}
+
+func tFuncVar(argc, iftab int) []byte {
+	return pack(uint8(ptgFuncVar|0x20), uint8(argc), uint16(iftab))
+}
+
+func tokens(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+// testBook is a BIFF8 workbook, or BIFF5 when biff5 is set.
+type testBook struct {
+	biff5   bool
+	globals []record
+	sheets  []testSheet
+}
+
+type testSheet struct {
+	name    string
+	records []record
+}
+
+func (b *testBook) bof(substream uint16) record {
+	version := uint16(XLS_BIFF8)
+	if b.biff5 {
+		version = XLS_BIFF7
+	}
+	return rec(XLS_TYPE_BOF, version, substream, uint16(0), uint16(0), uint32(0), uint32(0))
+}
+
+// stream returns the workbook stream: the globals with a SHEET record per sheet, then the sheets.
+func (b *testBook) stream() []byte {
+	globals := func(offsets []uint32) []byte {
+		records := append([]record{b.bof(XLS_WORKBOOKGLOBALS)}, b.globals...)
+		for i, sheet := range b.sheets {
+			name := shortString(sheet.name)
+			if b.biff5 {
+				name = pack(uint8(len(sheet.name)), sheet.name)
+			}
+			records = append(records, rec(XLS_TYPE_SHEET, offsets[i], uint16(0), name))
+		}
+		return encodeRecords(append(records, rec(XLS_TYPE_EOF)))
+	}
+
+	offsets := make([]uint32, len(b.sheets))
+	pos := uint32(len(globals(offsets)))
+	var sheets []byte
+	for i, sheet := range b.sheets {
+		offsets[i] = pos
+		records := append([]record{b.bof(XLS_WORKSHEET)}, sheet.records...)
+		data := encodeRecords(append(records, rec(XLS_TYPE_EOF)))
+		sheets = append(sheets, data...)
+		pos += uint32(len(data))
+	}
+	return append(globals(offsets), sheets...)
+}
+
+func encodeRecords(records []record) []byte {
+	var out []byte
+	for _, r := range records {
+		out = append(out, pack(r.typ, uint16(len(r.data)), r.data)...)
+	}
+	return out
+}
+
+// compoundFile stores stream as the Workbook stream of a compound file with 512 byte sectors:
+// the header, one FAT sector, one directory sector and the stream, which is never put in the mini stream.
+func compoundFile(stream []byte) []byte {
+	stream = append([]byte(nil), stream...)
+	for len(stream) < 4096 || len(stream)%512 != 0 {
+		stream = append(stream, 0)
+	}
+	sectors := len(stream) / 512
+	if sectors > 126 {
+		panic("compoundFile: stream too large")
+	}
+	le := binary.LittleEndian
+
+	header := make([]byte, 512)
+	copy(header, []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1})
+	le.PutUint16(header[0x18:], 0x3E)
+	le.PutUint16(header[0x1A:], 3)
+	le.PutUint16(header[0x1C:], 0xFFFE)
+	le.PutUint16(header[0x1E:], 9) // 512 byte sectors
+	le.PutUint16(header[0x20:], 6)
+	le.PutUint32(header[0x2C:], 1) // FAT sectors
+	le.PutUint32(header[0x30:], 1) // first directory sector
+	le.PutUint32(header[0x38:], 4096)
+	le.PutUint32(header[0x3C:], 0xFFFFFFFE)
+	le.PutUint32(header[0x44:], 0xFFFFFFFE)
+	for i := 0x4C; i < 512; i += 4 {
+		le.PutUint32(header[i:], 0xFFFFFFFF)
+	}
+	le.PutUint32(header[0x4C:], 0) // the FAT is sector 0
+
+	fat := make([]byte, 512)
+	for i := 0; i < 128; i++ {
+		le.PutUint32(fat[i*4:], 0xFFFFFFFF)
+	}
+	le.PutUint32(fat[0:], 0xFFFFFFFD)
+	le.PutUint32(fat[4:], 0xFFFFFFFE)
+	for i := 0; i < sectors; i++ {
+		next := uint32(3 + i)
+		if i == sectors-1 {
+			next = 0xFFFFFFFE
+		}
+		le.PutUint32(fat[(2+i)*4:], next)
+	}
+
+	dir := make([]byte, 512)
+	entry := func(i int, name string, typ byte, start, size uint32) {
+		e := dir[i*128:]
+		copy(e, utf16Bytes(name))
+		le.PutUint16(e[0x40:], uint16(len(utf16Bytes(name))+2))
+		e[0x42] = typ
+		le.PutUint32(e[0x44:], 0xFFFFFFFF)
+		le.PutUint32(e[0x48:], 0xFFFFFFFF)
+		le.PutUint32(e[0x4C:], 0xFFFFFFFF)
+		le.PutUint32(e[0x74:], start)
+		le.PutUint32(e[0x78:], size)
+	}
+	entry(0, "Root Entry", 5, 0xFFFFFFFE, 0)
+	le.PutUint32(dir[0x4C:], 1) // child of the root
+	entry(1, "Workbook", 2, 2, uint32(len(stream)))
+
+	return bytes.Join([][]byte{header, fat, dir, stream}, nil)
+}
+
+func (b *testBook) bytes() []byte {
+	return compoundFile(b.stream())
+}
+
+func openTestBook(t *testing.T, b *testBook) *XLS {
+	t.Helper()
+	xls, err := OpenBytes(b.bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return xls
+}
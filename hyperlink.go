@@ -0,0 +1,215 @@
+package xls
+
+import (
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	HyperlinkURL      = "url"      // web or mail address, e.g. http://example.org
+	HyperlinkFile     = "file"     // local or relative file path, e.g. ..\docs\report.xls
+	HyperlinkUNC      = "unc"      // network path, e.g. \\server\share\report.xls
+	HyperlinkWorkbook = "workbook" // location in the same workbook, e.g. Sheet2!A1
+	HyperlinkUnknown  = "unknown"  // target with a moniker this package does not decode, e.g. a composite moniker
+)
+
+// Hyperlink is a link of a block of cells from a HYPERLINK record.
+type Hyperlink struct {
+	Range Range
+	Type  string // HyperlinkURL, HyperlinkFile, ...
+
+	// Address is the URL or file path of the target, empty for links within the workbook
+	Address string
+	// Location is the position within the target, the text after '#', e.g. "Sheet2!A1"
+	Location string
+
+	// Display is the text shown for the link, Tooltip the text of the HLINKTOOLTIP record
+	Display string
+	Tooltip string
+	// TargetFrame is the frame the link is opened in, e.g. "_blank"
+	TargetFrame string
+}
+
+// URL returns the target of the link as a single string, the address followed by '#' and the location.
+func (h Hyperlink) URL() string {
+	if h.Location == "" {
+		return h.Address
+	}
+	return h.Address + "#" + h.Location
+}
+
+// GUIDs of the monikers of hyperlink targets, as stored in the file
+var (
+	urlMonikerGUID  = []byte{0xE0, 0xC9, 0xEA, 0x79, 0xF9, 0xBA, 0xCE, 0x11, 0x8C, 0x82, 0x00, 0xAA, 0x00, 0x4B, 0xA9, 0x0B}
+	fileMonikerGUID = []byte{0x03, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xC0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x46}
+)
+
+// readHyperlinkData decodes the hyperlink object of a HYPERLINK record (MS-OSHARED 2.3.7.1),
+// the data following the cell range. A target with an unknown moniker has the type HyperlinkUnknown and an empty address.
+func (xls *XLS) readHyperlinkData(data []byte) (*Hyperlink, error) {
+	if len(data) < 24 {
+		return nil, ErrTruncated
+	}
+
+	// offset: 0; size: 16; GUID of StdLink
+	// offset: 16; size: 4; stream version, always 2
+	// offset: 20; size: 4; option flags
+	flags := getInt4d(data, 20)
+	// bit: 0; mask: 0x0001; has a moniker (file link or URL)
+	hasMoniker := flags&0x0001 != 0
+	// bit: 3; mask: 0x0008; has a location (text mark)
+	hasLocation := flags&0x0008 != 0
+	// bit: 4; mask: 0x0010; has a display string
+	hasDisplay := flags&0x0010 != 0
+	// bit: 7; mask: 0x0080; has a target frame
+	hasFrame := flags&0x0080 != 0
+	// bit: 8; mask: 0x0100; moniker saved as a string (UNC path)
+	isUNC := flags&0x0100 != 0
+
+	h := &Hyperlink{Type: HyperlinkWorkbook}
+	pos := 24
+	var err error
+
+	if hasDisplay {
+		// offset: var; size: var; display string, character count and zero-terminated 16-bit characters
+		if h.Display, pos, err = readHyperlinkString(data, pos); err != nil {
+			return nil, err
+		}
+	}
+	if hasFrame {
+		// offset: var; size: var; target frame
+		if h.TargetFrame, pos, err = readHyperlinkString(data, pos); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case hasMoniker && isUNC:
+		// offset: var; size: var; UNC path
+		h.Type = HyperlinkUNC
+		if h.Address, pos, err = readHyperlinkString(data, pos); err != nil {
+			return nil, err
+		}
+	case hasMoniker:
+		if pos+16 > len(data) {
+			return nil, ErrTruncated
+		}
+		// offset: var; size: 16; GUID of the moniker
+		guid := data[pos : pos+16]
+		pos += 16
+		switch {
+		case equal(guid, urlMonikerGUID):
+			h.Type = HyperlinkURL
+			pos, err = h.readURLMoniker(data, pos)
+		case equal(guid, fileMonikerGUID):
+			h.Type = HyperlinkFile
+			pos, err = xls.readFileMoniker(h, data, pos)
+		default:
+			// composite and item monikers are not decoded, the location follows them and cannot be found
+			h.Type = HyperlinkUnknown
+			return h, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if hasLocation {
+		// offset: var; size: var; location, e.g. Sheet2!B1:C2
+		if h.Location, _, err = readHyperlinkString(data, pos); err != nil {
+			return nil, err
+		}
+	}
+	return h, nil
+}
+
+// readURLMoniker reads the data of a URL moniker and returns the position after it.
+func (h *Hyperlink) readURLMoniker(data []byte, pos int) (int, error) {
+	// offset: 0; size: 4; size in bytes of the following data
+	size := getInt4d(data, pos)
+	pos += 4
+	if size < 0 || pos+size > len(data) {
+		return 0, ErrTruncated
+	}
+
+	// offset: 4; size: var; URL, zero-terminated 16-bit characters, optionally followed by
+	// the GUID, version and flags of the URL
+	h.Address = utf16String(data[pos : pos+size])
+	return pos + size, nil
+}
+
+// readFileMoniker reads the data of a file moniker and returns the position after it.
+func (xls *XLS) readFileMoniker(h *Hyperlink, data []byte, pos int) (int, error) {
+	if pos+6 > len(data) {
+		return 0, ErrTruncated
+	}
+
+	// offset: 0; size: 2; directory up-level count
+	upLevel := int(getUInt2d(data, pos))
+	// offset: 2; size: 4; character count of the 8.3 file path, including the trailing zero
+	ansiLength := getInt4d(data, pos+2)
+	pos += 6
+	if ansiLength < 0 || pos+ansiLength+24+4 > len(data) {
+		return 0, ErrTruncated
+	}
+
+	// offset: 6; size: var; 8.3 file path, zero-terminated 8-bit characters
+	path := string(data[pos : pos+ansiLength])
+	if i := strings.IndexByte(path, 0); i >= 0 {
+		path = path[:i]
+	}
+	path = xls.decodeCodepage(path)
+	pos += ansiLength
+
+	// offset: var; size: 24; end server, version number and reserved fields
+	pos += 24
+
+	// offset: var; size: 4; size of the extended file path fields, 0 when absent
+	extSize := getInt4d(data, pos)
+	pos += 4
+	if extSize > 0 {
+		if pos+6 > len(data) {
+			return 0, ErrTruncated
+		}
+		// offset: var; size: 4; size in bytes of the extended file path
+		extLength := getInt4d(data, pos)
+		// offset: var; size: 2; key value, always 3
+		pos += 6
+		if extLength < 0 || pos+extLength > len(data) {
+			return 0, ErrTruncated
+		}
+		// offset: var; size: var; extended file path, 16-bit characters without trailing zero
+		path = utf16String(data[pos : pos+extLength])
+		pos += extLength
+	}
+
+	h.Address = strings.Repeat(`..\`, upLevel) + path
+	return pos, nil
+}
+
+// readHyperlinkString reads a string of a hyperlink object, a character count followed by
+// zero-terminated 16-bit characters, and returns the position after it.
+func readHyperlinkString(data []byte, pos int) (string, int, error) {
+	// offset: 0; size: 4; character count, including the trailing zero
+	length := getInt4d(data, pos)
+	pos += 4
+	if pos > len(data) || length < 0 || pos+2*length > len(data) {
+		return "", 0, ErrTruncated
+	}
+
+	// offset: 4; size: 2 * length; characters
+	return utf16String(data[pos : pos+2*length]), pos + 2*length, nil
+}
+
+// utf16String decodes little-endian 16-bit characters up to the first zero character.
+func utf16String(data []byte) string {
+	chars := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		c := getUInt2d(data, i)
+		if c == 0 {
+			break
+		}
+		chars = append(chars, c)
+	}
+	return string(utf16.Decode(chars))
+}
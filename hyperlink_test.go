@@ -0,0 +1,94 @@
+package xls
+
+import (
+	"errors"
+	"testing"
+)
+
+// hyperlinkString is a character count including the terminating zero, followed by the 16-bit characters.
+func hyperlinkString(s string) []byte {
+	return pack(uint32(len(utf16Bytes(s))/2+1), utf16Bytes(s), uint16(0))
+}
+
+func hyperlinkRec(rng Range, flags uint32, parts ...interface{}) record {
+	header := []interface{}{uint16(rng.FirstRow), uint16(rng.LastRow), uint16(rng.FirstCol), uint16(rng.LastCol),
+		make([]byte, 16), uint32(2), flags}
+	return rec(XLS_TYPE_HYPERLINK, append(header, parts...)...)
+}
+
+func TestHyperlinks(t *testing.T) {
+	url := append(utf16Bytes("http://example.org/"), 0, 0)
+	path := utf16Bytes(`docs\report.xls`)
+	compositeMonikerGUID := []byte{0x09, 0x03, 0, 0, 0, 0, 0, 0, 0xC0, 0, 0, 0, 0, 0, 0, 0x46}
+
+	book := &testBook{sheets: []testSheet{{name: "S", records: []record{
+		labelRec(0, 0, "link"),
+		labelRec(3, 4, "x"),
+		// URL with display text, location and tooltip
+		hyperlinkRec(Range{0, 0, 0, 0}, 0x01|0x02|0x08|0x10|0x14,
+			hyperlinkString("Example"), urlMonikerGUID, uint32(len(url)), url, hyperlinkString("top")),
+		rec(XLS_TYPE_HLINKTOOLTIP, uint16(XLS_TYPE_HLINKTOOLTIP), uint16(0), uint16(0), uint16(0), uint16(0),
+			utf16Bytes("Go there"), uint16(0)),
+		// relative file two directories up, with the Unicode path in the extended part
+		hyperlinkRec(Range{1, 2, 0, 1}, 0x01|0x08,
+			fileMonikerGUID, uint16(2), uint32(9), "DOC~1.XL\x00", make([]byte, 24),
+			uint32(6+len(path)), uint32(len(path)), uint16(3), path, hyperlinkString("Sheet1!A1")),
+		// file with the 8-bit path only
+		hyperlinkRec(Range{3, 3, 2, 2}, 0x01, fileMonikerGUID, uint16(0), uint32(6), "A.TXT\x00", make([]byte, 24), uint32(0)),
+		// UNC path
+		hyperlinkRec(Range{3, 3, 0, 0}, 0x01|0x02|0x100, hyperlinkString(`\\srv\share\a.xls`)),
+		// location in the workbook
+		hyperlinkRec(Range{3, 3, 1, 1}, 0x08, hyperlinkString("Sheet2!A1")),
+		// composite moniker, not decoded
+		hyperlinkRec(Range{3, 3, 4, 4}, 0x01|0x08, compositeMonikerGUID, make([]byte, 8)),
+	}}}}
+	sheet := openTestBook(t, book).Sheets()[0]
+	if n := len(sheet.Hyperlinks()); n != 6 {
+		t.Fatalf("got %d hyperlinks, want 6", n)
+	}
+
+	cases := []struct {
+		row, col int
+		want     Hyperlink
+	}{
+		{0, 0, Hyperlink{Range: Range{0, 0, 0, 0}, Type: HyperlinkURL, Address: "http://example.org/", Location: "top",
+			Display: "Example", Tooltip: "Go there"}},
+		{2, 1, Hyperlink{Range: Range{1, 2, 0, 1}, Type: HyperlinkFile, Address: `..\..\docs\report.xls`, Location: "Sheet1!A1"}},
+		{3, 2, Hyperlink{Range: Range{3, 3, 2, 2}, Type: HyperlinkFile, Address: "A.TXT"}},
+		{3, 0, Hyperlink{Range: Range{3, 3, 0, 0}, Type: HyperlinkUNC, Address: `\\srv\share\a.xls`}},
+		{3, 1, Hyperlink{Range: Range{3, 3, 1, 1}, Type: HyperlinkWorkbook, Location: "Sheet2!A1"}},
+		{3, 4, Hyperlink{Range: Range{3, 3, 4, 4}, Type: HyperlinkUnknown}},
+	}
+	for _, c := range cases {
+		got, ok := sheet.Row(c.row).Cell(c.col).Hyperlink()
+		if !ok || got != c.want {
+			t.Errorf("%s: got %+v, want %+v", formatCellAddress(c.row, c.col, true, true), got, c.want)
+		}
+	}
+
+	link, _ := sheet.Row(0).Cell(0).Hyperlink()
+	if got := link.URL(); got != "http://example.org/#top" {
+		t.Errorf("URL: got %q", got)
+	}
+}
+
+func TestHyperlinkDataErrors(t *testing.T) {
+	xls := &XLS{version: XLS_BIFF8}
+	header := pack(make([]byte, 16), uint32(2))
+	cases := map[string][]byte{
+		"short header":         make([]byte, 20),
+		"display past the end": pack(header, uint32(0x14), uint32(100), utf16Bytes("ab")),
+		"moniker without GUID": pack(header, uint32(0x01), make([]byte, 8)),
+		"URL past the end":     pack(header, uint32(0x01), urlMonikerGUID, uint32(100), utf16Bytes("http")),
+	}
+	for name, data := range cases {
+		if _, err := xls.readHyperlinkData(data); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+
+	book := &testBook{sheets: []testSheet{{name: "S", records: []record{rec(XLS_TYPE_HYPERLINK, 0, 0)}}}}
+	if _, err := OpenBytes(book.bytes()); !errors.Is(err, ErrTruncated) {
+		t.Errorf("short HYPERLINK record: got %v, want ErrTruncated", err)
+	}
+}
@@ -0,0 +1,164 @@
+package xls
+
+import "strings"
+
+// builtInNames maps the one-character names of built-in DEFINEDNAME records to their text.
+var builtInNames = map[byte]string{
+	0x00: "Consolidate_Area",
+	0x01: "Auto_Open",
+	0x02: "Auto_Close",
+	0x03: "Extract",
+	0x04: "Database",
+	0x05: "Criteria",
+	0x06: "Print_Area",
+	0x07: "Print_Titles",
+	0x08: "Recorder",
+	0x09: "Data_Form",
+	0x0A: "Auto_Activate",
+	0x0B: "Auto_Deactivate",
+	0x0C: "Sheet_Title",
+	0x0D: "_FilterDatabase",
+}
+
+// definedName is a name defined in the workbook globals.
+type definedName struct {
+	name    string
+	hidden  bool
+	builtIn bool
+
+	// sheetIndex is the zero-based index of the sheet the name is local to, or -1 for global names
+	sheetIndex int
+
+	// formula is the parsed expression defining the name, extra the additional data following it
+	formula []byte
+	extra   []byte
+}
+
+// Name is a defined name of the workbook, e.g. a named range or the print area of a sheet.
+type Name struct {
+	Name    string
+	Hidden  bool
+	BuiltIn bool // Print_Area, Print_Titles, _FilterDatabase, ...
+
+	// Sheet is the sheet the name is local to, nil for global names
+	Sheet *Sheet
+
+	// Formula is the definition of the name as formula text without the leading '=',
+	// e.g. "Invoice!$A$5:$F$40"; empty when it cannot be decoded
+	Formula string
+	// Areas are the cell ranges the name refers to, nil when the definition is not made of references
+	// of this workbook, e.g. a constant or a calculation
+	Areas []NameArea
+}
+
+// NameArea is a range of cells of a sheet.
+type NameArea struct {
+	Sheet *Sheet
+	Range Range
+}
+
+// Names returns the defined names of the workbook.
+func (xls *XLS) Names() []Name {
+	names := make([]Name, 0, len(xls.definedNames))
+	for _, name := range xls.definedNames {
+		names = append(names, xls.resolveName(name))
+	}
+	return names
+}
+
+// Name returns the defined name with the given name, ignoring case. Global names are preferred
+// over names local to a sheet, use Sheet.DefinedName to look up a name in the scope of a sheet.
+func (xls *XLS) Name(name string) (Name, bool) {
+	var local *definedName
+	for _, dn := range xls.definedNames {
+		if !strings.EqualFold(dn.name, name) {
+			continue
+		}
+		if dn.sheetIndex < 0 {
+			return xls.resolveName(dn), true
+		}
+		if local == nil {
+			local = dn
+		}
+	}
+	if local == nil {
+		return Name{}, false
+	}
+	return xls.resolveName(local), true
+}
+
+// DefinedName returns the defined name with the given name as seen from the sheet, ignoring case:
+// the name local to the sheet, otherwise the global name.
+func (s *Sheet) DefinedName(name string) (Name, bool) {
+	var global *definedName
+	for _, dn := range s.xls.definedNames {
+		if !strings.EqualFold(dn.name, name) {
+			continue
+		}
+		if dn.sheetIndex >= 0 && dn.sheetIndex < len(s.xls.sheets) && s.xls.sheets[dn.sheetIndex] == s {
+			return s.xls.resolveName(dn), true
+		}
+		if dn.sheetIndex < 0 && global == nil {
+			global = dn
+		}
+	}
+	if global == nil {
+		return Name{}, false
+	}
+	return s.xls.resolveName(global), true
+}
+
+// resolveName decodes the definition of a defined name.
+func (xls *XLS) resolveName(dn *definedName) Name {
+	name := Name{Name: dn.name, Hidden: dn.hidden, BuiltIn: dn.builtIn}
+	if dn.sheetIndex >= 0 && dn.sheetIndex < len(xls.sheets) {
+		name.Sheet = xls.sheets[dn.sheetIndex]
+	}
+
+	tokens, err := xls.parseFormula(dn.formula, dn.extra, 0, 0)
+	if err != nil {
+		return name
+	}
+	if name.Formula, err = decompileFormula(tokens); err != nil {
+		name.Formula = ""
+	}
+	if areas, ok := xls.nameAreas(tokens, name.Sheet); ok && len(areas) > 0 {
+		name.Areas = areas
+	}
+	return name
+}
+
+// nameAreas collects the ranges of a definition consisting of references and unions of references.
+// References without a sheet refer to the sheet of local names. It reports false for other definitions.
+func (xls *XLS) nameAreas(tokens []ptg, sheet *Sheet) ([]NameArea, bool) {
+	var areas []NameArea
+	for _, token := range tokens {
+		switch {
+		case token.area != nil:
+			a := token.area
+			if a.firstSheet < 0 {
+				if sheet == nil {
+					return nil, false
+				}
+				areas = append(areas, NameArea{Sheet: sheet, Range: a.rng})
+				continue
+			}
+			for i := a.firstSheet; i <= a.lastSheet; i++ {
+				if i >= len(xls.sheets) {
+					return nil, false
+				}
+				areas = append(areas, NameArea{Sheet: xls.sheets[i], Range: a.rng})
+			}
+		case token.id == ptgMemArea, token.id == ptgMemFunc, token.id == ptgMemNoMem:
+			sub, ok := xls.nameAreas(token.sub, sheet)
+			if !ok {
+				return nil, false
+			}
+			areas = append(areas, sub...)
+		case token.id == ptgUnion, token.id == ptgParen, token.id == ptgAttr && token.attr&ptgAttrSpace != 0:
+		default:
+			return nil, false
+		}
+	}
+	return areas, true
+}
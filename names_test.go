@@ -0,0 +1,79 @@
+package xls
+
+import (
+	"reflect"
+	"testing"
+)
+
+// nameRec is a DEFINEDNAME record, local to the one-based sheet or global for 0.
+func nameRec(options int, sheet int, name string, rgce []byte) record {
+	return rec(XLS_TYPE_DEFINEDNAME, uint16(options), uint8(0), uint8(len(name)), uint16(len(rgce)), uint16(0),
+		uint16(sheet), uint32(0), uint8(0), name, rgce)
+}
+
+func tArea3d(ixti, firstRow, lastRow, firstCol, lastCol int) []byte {
+	return pack(uint8(ptgArea3d|0x20), uint16(ixti), uint16(firstRow), uint16(lastRow), uint16(firstCol), uint16(lastCol))
+}
+
+func TestNames(t *testing.T) {
+	book := &testBook{
+		globals: []record{
+			rec(XLS_TYPE_EXTERNALBOOK, uint16(3), uint8(1), uint8(4)),
+			// S1, Sheet2:Sheet3
+			rec(XLS_TYPE_EXTERNSHEET, uint16(2), uint16(0), uint16(0), uint16(0), uint16(0), uint16(1), uint16(2)),
+			nameRec(0, 0, "Total", tArea3d(1, 0, 1, 0, 1)),
+			nameRec(0, 2, "total", pack(uint8(ptgArea|0x20), uint16(0), uint16(2), uint16(2), uint16(2))),
+			nameRec(0x20, 1, "\x06", tArea3d(0, 0, 9, 0, 3)),
+			nameRec(0, 0, "Rate", pack(uint8(ptgNum), 0.05)),
+			nameRec(0x01, 3, "Items", tokens(tArea3d(0, 0, 0, 0, 0), tArea3d(1, 4, 4, 1, 1), pack(uint8(ptgUnion)))),
+		},
+		sheets: []testSheet{{name: "S1"}, {name: "Sheet2"}, {name: "Sheet3"}},
+	}
+	xls := openTestBook(t, book)
+	s1, sheet2, sheet3 := xls.Sheets()[0], xls.Sheets()[1], xls.Sheets()[2]
+
+	if n := len(xls.Names()); n != 5 {
+		t.Fatalf("got %d names, want 5", n)
+	}
+
+	cases := []struct {
+		lookup string
+		got    func() (Name, bool)
+		want   Name
+	}{
+		{"global name before the local one", func() (Name, bool) { return xls.Name("TOTAL") }, Name{
+			Name: "Total", Formula: "'Sheet2:Sheet3'!$A$1:$B$2",
+			Areas: []NameArea{{sheet2, Range{0, 1, 0, 1}}, {sheet3, Range{0, 1, 0, 1}}},
+		}},
+		{"local name from its sheet", func() (Name, bool) { return sheet2.DefinedName("Total") }, Name{
+			Name: "total", Sheet: sheet2, Formula: "$C$1:$C$3",
+			Areas: []NameArea{{sheet2, Range{0, 2, 2, 2}}},
+		}},
+		{"global name from another sheet", func() (Name, bool) { return s1.DefinedName("total") }, Name{
+			Name: "Total", Formula: "'Sheet2:Sheet3'!$A$1:$B$2",
+			Areas: []NameArea{{sheet2, Range{0, 1, 0, 1}}, {sheet3, Range{0, 1, 0, 1}}},
+		}},
+		{"built-in name", func() (Name, bool) { return xls.Name("print_area") }, Name{
+			Name: "Print_Area", BuiltIn: true, Sheet: s1, Formula: "'S1'!$A$1:$D$10",
+			Areas: []NameArea{{s1, Range{0, 9, 0, 3}}},
+		}},
+		{"constant", func() (Name, bool) { return xls.Name("Rate") }, Name{Name: "Rate", Formula: "0.05"}},
+		{"only a local name", func() (Name, bool) { return xls.Name("Items") }, Name{
+			Name: "Items", Hidden: true, Sheet: sheet3, Formula: "'S1'!$A$1:$A$1,'Sheet2:Sheet3'!$B$5:$B$5",
+			Areas: []NameArea{{s1, Range{0, 0, 0, 0}}, {sheet2, Range{4, 4, 1, 1}}, {sheet3, Range{4, 4, 1, 1}}},
+		}},
+	}
+	for _, c := range cases {
+		got, ok := c.got()
+		if !ok || !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %+v, %v, want %+v", c.lookup, got, ok, c.want)
+		}
+	}
+
+	if _, ok := s1.DefinedName("Items"); ok {
+		t.Error("the name local to Sheet3 is visible from S1")
+	}
+	if _, ok := xls.Name("Missing"); ok {
+		t.Error("found an undefined name")
+	}
+}
@@ -3,6 +3,7 @@ package xls
 import (
 	"errors"
 	"io"
+	"io/fs"
 	"os"
 	"strings"
 )
@@ -55,41 +56,62 @@ type Property struct {
 }
 
 func readOLE(filename string) (*OLE, error) {
-	file, err := os.Open(filename)
+	data, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
-	defer func(file *os.File) {
-		_ = file.Close()
-	}(file)
 
-	// Read the file identifier
-	header := make([]byte, 8)
-	_, err = file.Read(header)
+	return newOLE(data)
+}
+
+func readOLEReader(r io.ReaderAt, size int64) (*OLE, error) {
+	data, err := io.ReadAll(io.NewSectionReader(r, 0, size))
 	if err != nil {
 		return nil, err
 	}
 
+	return newOLE(data)
+}
+
+func readOLEFS(fsys fs.FS, name string) (*OLE, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return newOLE(data)
+}
+
+func newOLE(data []byte) (*OLE, error) {
 	// Check OLE identifier
 	identifierOle := []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
-	if !equal(header, identifierOle) {
-		return nil, errors.New("the filename is not recognised as an OLE file")
+	if len(data) < len(identifierOle) || !equal(data[:len(identifierOle)], identifierOle) {
+		return nil, errors.New("the data is not recognised as an OLE file")
 	}
 
-	// Get the file data
-	data, err := io.ReadAll(file)
-	if err != nil {
-		return nil, err
+	ole := &OLE{
+		data:                       data,
+		wrkbook:                    -1,
+		summaryInformation:         -1,
+		documentSummaryInformation: -1,
 	}
 
-	ole := &OLE{data: append(header, data...)}
+	if err := ole.parseHeaders(); err != nil {
+		return nil, err
+	}
 
-	ole.parseHeaders()
+	if ole.wrkbook == -1 {
+		return nil, ErrNoWorkbook
+	}
 
 	return ole, nil
 }
 
-func (ole *OLE) parseHeaders() {
+func (ole *OLE) parseHeaders() error {
+	if len(ole.data) < BIG_BLOCK_SIZE {
+		return &OLEError{Sector: -1, Err: ErrTruncated}
+	}
+
 	// Total number of sectors used for the SAT
 	ole.numBigBlockDepotBlocks = getInt4d(ole.data, NUM_BIG_BLOCK_DEPOT_BLOCKS_POS)
 
@@ -105,6 +127,13 @@ func (ole *OLE) parseHeaders() {
 	// Total number of sectors used by MSAT
 	ole.numExtensionBlocks = getInt4d(ole.data, NUM_EXTENSION_BLOCK_POS)
 
+	// every SAT sector and every MSAT sector must exist in the file
+	numSectors := len(ole.data)/BIG_BLOCK_SIZE - 1
+	if ole.numBigBlockDepotBlocks < 0 || ole.numBigBlockDepotBlocks > numSectors ||
+		ole.numExtensionBlocks < 0 || ole.numExtensionBlocks > numSectors {
+		return &OLEError{Sector: -1, Err: ErrCorrupt}
+	}
+
 	// Read the big block depot blocks
 	bigBlockDepotBlocks := make([]int, ole.numBigBlockDepotBlocks)
 	pos := BIG_BLOCK_DEPOT_BLOCKS_POS
@@ -112,6 +141,11 @@ func (ole *OLE) parseHeaders() {
 
 	if ole.numExtensionBlocks != 0 {
 		bbdBlocks = (BIG_BLOCK_SIZE - BIG_BLOCK_DEPOT_BLOCKS_POS) / 4
+		if bbdBlocks > ole.numBigBlockDepotBlocks {
+			return &OLEError{Sector: -1, Err: ErrCorrupt}
+		}
+	} else if bbdBlocks > (BIG_BLOCK_SIZE-BIG_BLOCK_DEPOT_BLOCKS_POS)/4 {
+		return &OLEError{Sector: -1, Err: ErrCorrupt}
 	}
 
 	for i := 0; i < bbdBlocks; i++ {
@@ -120,7 +154,10 @@ func (ole *OLE) parseHeaders() {
 	}
 
 	for j := 0; j < ole.numExtensionBlocks; j++ {
-		pos = (ole.extensionBlock + 1) * BIG_BLOCK_SIZE
+		pos, err := ole.sectorPos(ole.extensionBlock)
+		if err != nil {
+			return err
+		}
 		blocksToRead := min(ole.numBigBlockDepotBlocks-bbdBlocks, BIG_BLOCK_SIZE/4-1)
 
 		for i := bbdBlocks; i < bbdBlocks+blocksToRead; i++ {
@@ -135,38 +172,54 @@ func (ole *OLE) parseHeaders() {
 	}
 
 	// Read the big block chain
-	pos = 0
 	ole.bigBlockChain = make([]byte, 0)
 	bbs := BIG_BLOCK_SIZE / 4
 	for i := 0; i < ole.numBigBlockDepotBlocks; i++ {
-		pos = (bigBlockDepotBlocks[i] + 1) * BIG_BLOCK_SIZE
+		pos, err := ole.sectorPos(bigBlockDepotBlocks[i])
+		if err != nil {
+			return err
+		}
+		if pos+4*bbs > len(ole.data) {
+			return &OLEError{Sector: bigBlockDepotBlocks[i], Err: ErrTruncated}
+		}
 		ole.bigBlockChain = append(ole.bigBlockChain, ole.data[pos:pos+4*bbs]...)
-		pos += 4 * bbs
 	}
 
 	// Read the small block chain
-	sbdBlock := ole.sbdStartBlock
-	ole.smallBlockChain = make([]byte, 0)
-	for sbdBlock != -2 {
-		pos = (sbdBlock + 1) * BIG_BLOCK_SIZE
-		ole.smallBlockChain = append(ole.smallBlockChain, ole.data[pos:pos+4*bbs]...)
-		pos += 4 * bbs
-		sbdBlock = getInt4d(ole.bigBlockChain, sbdBlock*4)
+	var err error
+	ole.smallBlockChain, err = ole.readChain(ole.sbdStartBlock, ole.bigBlockChain, func(block int) ([]byte, error) {
+		pos, err := ole.sectorPos(block)
+		if err != nil {
+			return nil, err
+		}
+		if pos+4*bbs > len(ole.data) {
+			return nil, &OLEError{Sector: block, Err: ErrTruncated}
+		}
+		return ole.data[pos : pos+4*bbs], nil
+	})
+	if err != nil {
+		return err
 	}
 
 	// Read the directory stream
 	block := ole.rootStartBlock
-	ole.entry = ole.readData(block)
+	ole.entry, err = ole.readData(block)
+	if err != nil {
+		return err
+	}
 
-	ole.readPropertySets()
+	return ole.readPropertySets()
 }
 
-func (ole *OLE) readPropertySets() {
+func (ole *OLE) readPropertySets() error {
 	offset := 0
 	entryLen := len(ole.entry)
-	for offset < entryLen {
+	for offset+PROPERTY_STORAGE_BLOCK_SIZE <= entryLen {
 		d := ole.entry[offset : offset+PROPERTY_STORAGE_BLOCK_SIZE]
 		nameSize := int(d[SIZE_OF_NAME_POS]) | (int(d[SIZE_OF_NAME_POS+1]) << 8)
+		if nameSize > SIZE_OF_NAME_POS {
+			return &OLEError{Sector: ole.rootStartBlock, Err: ErrCorrupt}
+		}
 		typ := int(d[TYPE_POS])
 		startBlock := getInt4d(d, START_BLOCK_POS)
 		size := getInt4d(d, SIZE_POS)
@@ -184,28 +237,35 @@ func (ole *OLE) readPropertySets() {
 		}
 		offset += PROPERTY_STORAGE_BLOCK_SIZE
 	}
+
+	return nil
 }
 
-func (ole *OLE) getStream(stream int) []byte {
-	if stream == -1 {
-		return nil
+func (ole *OLE) getStream(stream int) ([]byte, error) {
+	if stream < 0 || stream >= len(ole.props) {
+		return nil, nil
 	}
 
 	var streamData []byte
 
 	if ole.props[stream].size < SMALL_BLOCK_THRESHOLD {
-		rootdata := ole.readData(ole.props[ole.rootentry].startBlock)
-
-		block := ole.props[stream].startBlock
+		rootdata, err := ole.readData(ole.props[ole.rootentry].startBlock)
+		if err != nil {
+			return nil, err
+		}
 
-		for block != -2 {
+		streamData, err = ole.readChain(ole.props[stream].startBlock, ole.smallBlockChain, func(block int) ([]byte, error) {
 			pos := block * SMALL_BLOCK_SIZE
-			streamData = append(streamData, rootdata[pos:pos+SMALL_BLOCK_SIZE]...)
-
-			block = getInt4d(ole.smallBlockChain, block*4)
+			if pos+SMALL_BLOCK_SIZE > len(rootdata) {
+				return nil, &OLEError{Sector: block, Err: ErrTruncated}
+			}
+			return rootdata[pos : pos+SMALL_BLOCK_SIZE], nil
+		})
+		if err != nil {
+			return nil, err
 		}
 
-		return streamData
+		return streamData, nil
 	}
 
 	numBlocks := ole.props[stream].size / BIG_BLOCK_SIZE
@@ -214,27 +274,47 @@ func (ole *OLE) getStream(stream int) []byte {
 	}
 
 	if numBlocks == 0 {
-		return nil
+		return nil, nil
 	}
 
-	block := ole.props[stream].startBlock
+	return ole.readData(ole.props[stream].startBlock)
+}
 
-	for block != -2 {
-		pos := (block + 1) * BIG_BLOCK_SIZE
-		streamData = append(streamData, ole.data[pos:pos+BIG_BLOCK_SIZE]...)
+func (ole *OLE) readData(block int) ([]byte, error) {
+	return ole.readChain(block, ole.bigBlockChain, func(block int) ([]byte, error) {
+		pos, err := ole.sectorPos(block)
+		if err != nil {
+			return nil, err
+		}
+		// tolerate a short final sector, some writers do not pad the file
+		return ole.data[pos:min(pos+BIG_BLOCK_SIZE, len(ole.data))], nil
+	})
+}
 
-		block = getInt4d(ole.bigBlockChain, block*4)
+// readChain follows a sector chain starting at block through the given allocation table
+// and concatenates the sectors returned by read. Chains that loop or leave the table are reported as corrupt.
+func (ole *OLE) readChain(block int, chain []byte, read func(block int) ([]byte, error)) ([]byte, error) {
+	data := make([]byte, 0)
+	maxBlocks := len(chain) / 4
+	for n := 0; block != -2; n++ {
+		if block < 0 || block >= maxBlocks || n >= maxBlocks {
+			return nil, &OLEError{Sector: block, Err: ErrCorrupt}
+		}
+		sector, err := read(block)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, sector...)
+		block = getInt4d(chain, block*4)
 	}
-
-	return streamData
+	return data, nil
 }
 
-func (ole *OLE) readData(block int) []byte {
-	data := make([]byte, 0)
-	for block != -2 {
-		pos := (block + 1) * BIG_BLOCK_SIZE
-		data = append(data, ole.data[pos:pos+BIG_BLOCK_SIZE]...)
-		block = getInt4d(ole.bigBlockChain, block*4)
+// sectorPos returns the file offset of a big block sector.
+func (ole *OLE) sectorPos(block int) (int, error) {
+	pos := (block + 1) * BIG_BLOCK_SIZE
+	if block < 0 || pos >= len(ole.data) {
+		return 0, &OLEError{Sector: block, Err: ErrTruncated}
 	}
-	return data
+	return pos, nil
 }
@@ -0,0 +1,108 @@
+package xls
+
+import "sort"
+
+// Outline is the row and column grouping of a sheet.
+type Outline struct {
+	// SummaryBelow and SummaryRight tell whether summary rows are below their detail rows
+	// and summary columns right of their detail columns, the default
+	SummaryBelow bool
+	SummaryRight bool
+	// ShowSymbols is set when the outline symbols are displayed
+	ShowSymbols bool
+
+	// MaxRowLevel and MaxColLevel are the highest outline levels of the GUTS record
+	MaxRowLevel int
+	MaxColLevel int
+
+	// Rows and Cols are the top-level groups of rows and columns
+	Rows []*OutlineGroup
+	Cols []*OutlineGroup
+}
+
+// OutlineGroup is a group of rows or columns with the groups nested in it.
+type OutlineGroup struct {
+	// First and Last are the zero-based indexes of the rows or columns of the group
+	First int
+	Last  int
+	// Level is the outline level of the group, from 1 to 7
+	Level int
+	// Collapsed is set when the group is collapsed, as flagged on its summary row or column
+	Collapsed bool
+
+	Children []*OutlineGroup
+}
+
+// outlineEntry is the outline level of a row or column.
+type outlineEntry struct {
+	index int
+	level int
+}
+
+// Outline returns the group tree of the rows and columns of the sheet, built from the outline levels
+// of the ROW and COLINFO records and the settings of the GUTS and WSBOOL records.
+func (s *Sheet) Outline() Outline {
+	outline := Outline{
+		SummaryBelow: s.summaryBelow,
+		SummaryRight: s.summaryRight,
+		ShowSymbols:  s.showOutlineSymbols,
+		MaxRowLevel:  s.maxRowLevel,
+		MaxColLevel:  s.maxColLevel,
+	}
+
+	var rows []outlineEntry
+	for index, info := range s.rowInfos {
+		if info.outlineLevel > 0 {
+			rows = append(rows, outlineEntry{index: index, level: info.outlineLevel})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].index < rows[j].index })
+	outline.Rows = outlineGroups(rows, 1, s.summaryBelow, func(row int) bool {
+		info, ok := s.rowInfos[row]
+		return ok && info.collapsed
+	})
+
+	var cols []outlineEntry
+	for _, info := range s.colInfos {
+		if info.column.OutlineLevel == 0 {
+			continue
+		}
+		for col := info.firstCol; col <= min(info.lastCol, 0xFF); col++ {
+			cols = append(cols, outlineEntry{index: col, level: info.column.OutlineLevel})
+		}
+	}
+	sort.Slice(cols, func(i, j int) bool { return cols[i].index < cols[j].index })
+	outline.Cols = outlineGroups(cols, 1, s.summaryRight, func(col int) bool {
+		return s.Column(col).Collapsed
+	})
+
+	return outline
+}
+
+// outlineGroups splits entries sorted by index into the groups of a level: runs of adjacent rows or columns
+// with at least this level. The summary row or column follows the group when summaryAfter is set.
+func outlineGroups(entries []outlineEntry, level int, summaryAfter bool, collapsed func(index int) bool) []*OutlineGroup {
+	var groups []*OutlineGroup
+	for i := 0; i < len(entries); {
+		if entries[i].level < level {
+			i++
+			continue
+		}
+
+		j := i + 1
+		for j < len(entries) && entries[j].level >= level && entries[j].index == entries[j-1].index+1 {
+			j++
+		}
+
+		group := &OutlineGroup{First: entries[i].index, Last: entries[j-1].index, Level: level}
+		if summaryAfter {
+			group.Collapsed = collapsed(group.Last + 1)
+		} else {
+			group.Collapsed = group.First > 0 && collapsed(group.First-1)
+		}
+		group.Children = outlineGroups(entries[i:j], level+1, summaryAfter, collapsed)
+		groups = append(groups, group)
+		i = j
+	}
+	return groups
+}
@@ -0,0 +1,77 @@
+package xls
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOutline(t *testing.T) {
+	const collapsed, hidden = 0x10, 0x20
+	book := &testBook{sheets: []testSheet{
+		{name: "Below", records: []record{
+			rec(XLS_TYPE_GUTS, uint16(0), uint16(0), uint16(3), uint16(0)),
+			rec(XLS_TYPE_SHEETPR, uint16(0x0040|0x0080|0x0400)),
+			// rows 2-5 with the nested group 3-4 summed up in row 5, which is collapsed
+			rowRec(1, 255, 1),
+			rowRec(2, 255, 2|hidden),
+			rowRec(3, 255, 2|hidden),
+			rowRec(4, 255, 1|collapsed),
+			rowRec(5, 255, 0),
+			rowRec(8, 255, 1|hidden),
+			rowRec(9, 255, 1|hidden),
+			rowRec(10, 255, collapsed),
+			numberRec(10, 0, 1),
+		}},
+		{name: "Above", records: []record{
+			rec(XLS_TYPE_GUTS, uint16(0), uint16(0), uint16(2), uint16(3)),
+			rec(XLS_TYPE_SHEETPR, uint16(0)),
+			rowRec(0, 255, collapsed),
+			rowRec(1, 255, 1|hidden),
+			rowRec(2, 255, 1|hidden),
+			rowRec(5, 255, 1),
+			// columns B-D with the nested group C-D, summed up left of them in A and B
+			colInfoRec(0, 0, 2560, 15, 0x1000),
+			colInfoRec(1, 1, 2560, 15, 0x0100|0x0001),
+			colInfoRec(2, 3, 2560, 15, 0x0200|0x0001),
+			numberRec(5, 5, 1),
+		}},
+	}}
+	sheets := openTestBook(t, book).Sheets()
+
+	want := Outline{
+		SummaryBelow: true, SummaryRight: true, ShowSymbols: true, MaxRowLevel: 2,
+		Rows: []*OutlineGroup{
+			{First: 1, Last: 4, Level: 1, Children: []*OutlineGroup{{First: 2, Last: 3, Level: 2, Collapsed: true}}},
+			{First: 8, Last: 9, Level: 1, Collapsed: true},
+		},
+	}
+	if got := sheets[0].Outline(); !reflect.DeepEqual(got, want) {
+		t.Errorf("summary below:\ngot  %s\nwant %s", outlineString(got), outlineString(want))
+	}
+
+	want = Outline{
+		MaxRowLevel: 1, MaxColLevel: 2,
+		Rows: []*OutlineGroup{{First: 1, Last: 2, Level: 1, Collapsed: true}, {First: 5, Last: 5, Level: 1}},
+		Cols: []*OutlineGroup{
+			{First: 1, Last: 3, Level: 1, Collapsed: true, Children: []*OutlineGroup{{First: 2, Last: 3, Level: 2}}},
+		},
+	}
+	if got := sheets[1].Outline(); !reflect.DeepEqual(got, want) {
+		t.Errorf("summary above:\ngot  %s\nwant %s", outlineString(got), outlineString(want))
+	}
+}
+
+func outlineString(o Outline) string {
+	var groups func([]*OutlineGroup) string
+	groups = func(list []*OutlineGroup) string {
+		var parts []string
+		for _, g := range list {
+			parts = append(parts, fmt.Sprintf("%d-%d level %d collapsed %v %s", g.First, g.Last, g.Level, g.Collapsed, groups(g.Children)))
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	}
+	return fmt.Sprintf("%+v rows %s cols %s", Outline{SummaryBelow: o.SummaryBelow, SummaryRight: o.SummaryRight,
+		ShowSymbols: o.ShowSymbols, MaxRowLevel: o.MaxRowLevel, MaxColLevel: o.MaxColLevel}, groups(o.Rows), groups(o.Cols))
+}
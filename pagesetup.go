@@ -0,0 +1,189 @@
+package xls
+
+import "strings"
+
+const (
+	OrientationDefault   = 0x00
+	OrientationPortrait  = 0x01
+	OrientationLandscape = 0x02
+)
+
+// Fields of headers and footers.
+const (
+	HeaderText    = iota // literal text
+	HeaderPage           // &P, page number
+	HeaderPages          // &N, number of pages
+	HeaderDate           // &D, current date
+	HeaderTime           // &T, current time
+	HeaderFile           // &F, file name
+	HeaderPath           // &Z, file path
+	HeaderSheet          // &A, sheet name
+	HeaderPicture        // &G, picture
+)
+
+// PageSetup is the print setup of a sheet.
+type PageSetup struct {
+	// PaperSize is the paper size code, e.g. 1 for Letter or 9 for A4, 0 when not set
+	PaperSize   int
+	Orientation int // OrientationDefault, OrientationPortrait or OrientationLandscape
+
+	// Scale is the print scale in percent, used unless FitToPage is set
+	Scale int
+	// FitToPage is set when the sheet is scaled to FitToWidth pages wide and FitToHeight pages tall,
+	// 0 meaning as many pages as needed
+	FitToPage   bool
+	FitToWidth  int
+	FitToHeight int
+
+	// FirstPageNumber is the number of the first page, 0 for automatic numbering
+	FirstPageNumber int
+	// OverThenDown is set when pages are printed left to right, then top to bottom
+	OverThenDown  bool
+	BlackAndWhite bool
+	Draft         bool
+
+	Margins Margins
+
+	HorizontalCentered bool
+	VerticalCentered   bool
+	PrintGridlines     bool
+
+	Header HeaderFooter
+	Footer HeaderFooter
+}
+
+// Margins are the page margins in inches.
+type Margins struct {
+	Left, Right, Top, Bottom float64
+	Header, Footer           float64
+}
+
+// HeaderFooter is a page header or footer split into its sections.
+type HeaderFooter struct {
+	Left   HeaderSection
+	Center HeaderSection
+	Right  HeaderSection
+}
+
+// HeaderSection is the left, center or right part of a header or footer.
+type HeaderSection struct {
+	// Code is the text of the section with its control codes, e.g. "Page &P of &N"
+	Code string
+	// Parts are the text and fields of the section, without font and formatting codes
+	Parts []HeaderPart
+}
+
+// HeaderPart is a literal text or a field of a header or footer.
+type HeaderPart struct {
+	Field int // HeaderText, HeaderPage, ...
+	Text  string
+}
+
+var headerFields = map[byte]int{
+	'P': HeaderPage,
+	'N': HeaderPages,
+	'D': HeaderDate,
+	'T': HeaderTime,
+	'F': HeaderFile,
+	'Z': HeaderPath,
+	'A': HeaderSheet,
+	'G': HeaderPicture,
+}
+
+var headerFieldNames = map[int]string{
+	HeaderPage:    "&[Page]",
+	HeaderPages:   "&[Pages]",
+	HeaderDate:    "&[Date]",
+	HeaderTime:    "&[Time]",
+	HeaderFile:    "&[File]",
+	HeaderPath:    "&[Path]",
+	HeaderSheet:   "&[Tab]",
+	HeaderPicture: "&[Picture]",
+}
+
+// String returns the text of the section with the fields named as in Excel, e.g. "Page &[Page] of &[Pages]".
+func (s HeaderSection) String() string {
+	var sb strings.Builder
+	for _, part := range s.Parts {
+		if part.Field == HeaderText {
+			sb.WriteString(part.Text)
+		} else {
+			sb.WriteString(headerFieldNames[part.Field])
+		}
+	}
+	return sb.String()
+}
+
+// String returns the sections of the header or footer separated by '|', e.g. "|&[Tab]|Page &[Page]".
+func (h HeaderFooter) String() string {
+	if h.Left.Code == "" && h.Center.Code == "" && h.Right.Code == "" {
+		return ""
+	}
+	return h.Left.String() + "|" + h.Center.String() + "|" + h.Right.String()
+}
+
+// parseHeaderFooter splits the text of a HEADER or FOOTER record into its sections. &L, &C and &R start
+// the left, center and right sections, text before them belongs to the center section.
+func parseHeaderFooter(text string) HeaderFooter {
+	var h HeaderFooter
+	section := &h.Center
+	var literal strings.Builder
+
+	flush := func() {
+		if literal.Len() > 0 {
+			section.Parts = append(section.Parts, HeaderPart{Field: HeaderText, Text: literal.String()})
+			literal.Reset()
+		}
+	}
+
+	start := 0
+	for i := 0; i < len(text); i++ {
+		if text[i] != '&' || i+1 >= len(text) {
+			literal.WriteByte(text[i])
+			continue
+		}
+
+		i++
+		code := text[i]
+		switch {
+		case code == 'L' || code == 'C' || code == 'R':
+			flush()
+			section.Code += text[start : i-1]
+			start = i + 1
+			switch code {
+			case 'L':
+				section = &h.Left
+			case 'C':
+				section = &h.Center
+			default:
+				section = &h.Right
+			}
+		case code == '&':
+			literal.WriteByte('&')
+		case headerFields[code] != HeaderText:
+			flush()
+			section.Parts = append(section.Parts, HeaderPart{Field: headerFields[code]})
+		case code == '"':
+			// font name and style, e.g. &"Arial,Bold"
+			if end := strings.IndexByte(text[i+1:], '"'); end >= 0 {
+				i += end + 1
+			} else {
+				i = len(text)
+			}
+		case code >= '0' && code <= '9':
+			// font size
+			for i+1 < len(text) && text[i+1] >= '0' && text[i+1] <= '9' {
+				i++
+			}
+		case code == 'K':
+			// font color, RGB in hex or theme color and tint
+			i = min(i+6, len(text)-1)
+		default:
+			// bold, italic, underline and other font styles
+		}
+	}
+	flush()
+	section.Code += text[start:]
+
+	return h
+}
@@ -0,0 +1,92 @@
+package xls
+
+import (
+	"reflect"
+	"testing"
+)
+
+// pageSetupRec is a PAGESETUP record for A4 paper at 100%, with header and footer margins of 0.3 inches.
+func pageSetupRec(options int) record {
+	return rec(XLS_TYPE_PAGESETUP, uint16(9), uint16(100), uint16(1), uint16(1), uint16(1), uint16(options),
+		uint16(600), uint16(600), 0.3, 0.3, uint16(1))
+}
+
+func TestParseHeaderFooter(t *testing.T) {
+	text := func(s string) HeaderPart { return HeaderPart{Field: HeaderText, Text: s} }
+	field := func(f int) HeaderPart { return HeaderPart{Field: f} }
+
+	cases := []struct {
+		text string
+		want HeaderFooter
+	}{
+		{"", HeaderFooter{}},
+		{"Report", HeaderFooter{Center: HeaderSection{Code: "Report", Parts: []HeaderPart{text("Report")}}}},
+		{
+			"&LLeft&CPage &P of &N&R&D",
+			HeaderFooter{
+				Left:   HeaderSection{Code: "Left", Parts: []HeaderPart{text("Left")}},
+				Center: HeaderSection{Code: "Page &P of &N", Parts: []HeaderPart{text("Page "), field(HeaderPage), text(" of "), field(HeaderPages)}},
+				Right:  HeaderSection{Code: "&D", Parts: []HeaderPart{field(HeaderDate)}},
+			},
+		},
+		{
+			`&L&"Arial,Bold"&12Sales && Costs&R&KFF0000&F`,
+			HeaderFooter{
+				Left:  HeaderSection{Code: `&"Arial,Bold"&12Sales && Costs`, Parts: []HeaderPart{text("Sales & Costs")}},
+				Right: HeaderSection{Code: "&KFF0000&F", Parts: []HeaderPart{field(HeaderFile)}},
+			},
+		},
+		{"&B&IBold&U 100&", HeaderFooter{Center: HeaderSection{Code: "&B&IBold&U 100&", Parts: []HeaderPart{text("Bold 100&")}}}},
+		{"&R&A", HeaderFooter{Right: HeaderSection{Code: "&A", Parts: []HeaderPart{field(HeaderSheet)}}}},
+	}
+	for _, c := range cases {
+		if got := parseHeaderFooter(c.text); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%q:\ngot  %+v\nwant %+v", c.text, got, c.want)
+		}
+	}
+
+	h := parseHeaderFooter("&CPage &P of &N&R&A")
+	if got := h.String(); got != "|Page &[Page] of &[Pages]|&[Tab]" {
+		t.Errorf("String: got %q", got)
+	}
+}
+
+func TestPageSetup(t *testing.T) {
+	book := &testBook{sheets: []testSheet{
+		{name: "Portrait", records: []record{
+			rec(XLS_TYPE_HEADER, xlString("&CTitle")),
+			rec(XLS_TYPE_FOOTER),
+			rec(XLS_TYPE_HCENTER, uint16(1)),
+			rec(XLS_TYPE_LEFTMARGIN, 0.5),
+			rec(XLS_TYPE_TOPMARGIN, 1.0),
+			pageSetupRec(0x0002 | 0x0008 | 0x0080),
+		}},
+		// paper, scale and orientation not initialised, the defaults are kept
+		{name: "NotInit", records: []record{pageSetupRec(0x0004 | 0x0002)}},
+		{name: "Landscape", records: []record{pageSetupRec(0x0000)}},
+		{name: "NoOrientation", records: []record{pageSetupRec(0x0040 | 0x0001)}},
+	}}
+	sheets := openTestBook(t, book).Sheets()
+
+	ps := sheets[0].PageSetup()
+	if ps.PaperSize != 9 || ps.Scale != 100 || ps.Orientation != OrientationPortrait || !ps.BlackAndWhite ||
+		ps.FirstPageNumber != 1 || ps.OverThenDown || !ps.HorizontalCentered || ps.VerticalCentered {
+		t.Errorf("Portrait: got %+v", ps)
+	}
+	if m := ps.Margins; m.Left != 0.5 || m.Top != 1 || m.Header != 0.3 || m.Footer != 0.3 {
+		t.Errorf("Portrait margins: got %+v", m)
+	}
+	if ps.Header.Center.Code != "Title" || ps.Footer.String() != "" {
+		t.Errorf("Portrait header and footer: got %+v, %+v", ps.Header, ps.Footer)
+	}
+
+	if ps := sheets[1].PageSetup(); ps.PaperSize != 0 || ps.Scale != 100 || ps.Orientation != OrientationDefault {
+		t.Errorf("NotInit: got %+v", ps)
+	}
+	if ps := sheets[2].PageSetup(); ps.Orientation != OrientationLandscape || ps.FirstPageNumber != 0 {
+		t.Errorf("Landscape: got %+v", ps)
+	}
+	if ps := sheets[3].PageSetup(); ps.Orientation != OrientationDefault || !ps.OverThenDown {
+		t.Errorf("NoOrientation: got %+v", ps)
+	}
+}
@@ -0,0 +1,98 @@
+package xls
+
+import "sort"
+
+// PrintLayout is the pagination of a sheet from its manual page breaks and the Print_Area and Print_Titles names.
+type PrintLayout struct {
+	// Areas are the print areas, or the used range when no print area is defined;
+	// areas are cut at the last used row and column
+	Areas []Range
+
+	// TitleRows and TitleCols are the rows and columns repeated on every page, nil when not set
+	TitleRows *Range
+	TitleCols *Range
+
+	// RowBreaks and ColBreaks are the manual page breaks, the indexes of the first row or column of a new page
+	RowBreaks []int
+	ColBreaks []int
+
+	// Pages are the cells of each page in print order. The title rows and columns are printed in addition
+	// to them, title rows within an area are also part of the page they fall on as in Excel.
+	// Only manual page breaks are applied, automatic breaks depend on the rendering.
+	Pages []Range
+}
+
+// PrintLayout returns the print areas, titles, page breaks and resulting pages of the sheet.
+func (s *Sheet) PrintLayout() PrintLayout {
+	layout := PrintLayout{
+		RowBreaks: append([]int(nil), s.rowBreaks...),
+		ColBreaks: append([]int(nil), s.colBreaks...),
+	}
+	sort.Ints(layout.RowBreaks)
+	sort.Ints(layout.ColBreaks)
+
+	used := Range{FirstRow: 0, LastRow: s.maxRow, FirstCol: 0, LastCol: s.maxCol}
+	if name, ok := s.DefinedName("Print_Area"); ok && name.Sheet == s {
+		for _, area := range name.Areas {
+			if area.Sheet != s || area.Range.FirstRow > used.LastRow || area.Range.FirstCol > used.LastCol {
+				continue
+			}
+			rng := area.Range
+			rng.LastRow = min(rng.LastRow, used.LastRow)
+			rng.LastCol = min(rng.LastCol, used.LastCol)
+			layout.Areas = append(layout.Areas, rng)
+		}
+	} else {
+		layout.Areas = []Range{used}
+	}
+
+	if name, ok := s.DefinedName("Print_Titles"); ok && name.Sheet == s {
+		for _, area := range name.Areas {
+			rng := area.Range
+			switch {
+			case area.Sheet != s:
+			case rng.FirstCol == 0 && rng.LastCol >= 0xFF:
+				// whole rows
+				layout.TitleRows = &rng
+			case rng.FirstRow == 0 && rng.LastRow >= 0xFFFF:
+				// whole columns
+				layout.TitleCols = &rng
+			}
+		}
+	}
+
+	overThenDown := s.pageSetup.OverThenDown
+	for _, area := range layout.Areas {
+		rows := pageSpans(area.FirstRow, area.LastRow, layout.RowBreaks)
+		cols := pageSpans(area.FirstCol, area.LastCol, layout.ColBreaks)
+
+		outer, inner := cols, rows
+		if overThenDown {
+			outer, inner = rows, cols
+		}
+		for _, o := range outer {
+			for _, i := range inner {
+				page := Range{FirstRow: i[0], LastRow: i[1], FirstCol: o[0], LastCol: o[1]}
+				if overThenDown {
+					page = Range{FirstRow: o[0], LastRow: o[1], FirstCol: i[0], LastCol: i[1]}
+				}
+				layout.Pages = append(layout.Pages, page)
+			}
+		}
+	}
+
+	return layout
+}
+
+// pageSpans splits the rows or columns from first to last at the page breaks within them.
+func pageSpans(first, last int, breaks []int) [][2]int {
+	var spans [][2]int
+	start := first
+	for _, b := range breaks {
+		if b > start && b <= last {
+			spans = append(spans, [2]int{start, b - 1})
+			start = b
+		}
+	}
+	return append(spans, [2]int{start, last})
+}
@@ -0,0 +1,73 @@
+package xls
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPrintLayout(t *testing.T) {
+	book := &testBook{
+		globals: []record{
+			rec(XLS_TYPE_EXTERNALBOOK, uint16(2), uint8(1), uint8(4)),
+			rec(XLS_TYPE_EXTERNSHEET, uint16(2), uint16(0), uint16(0), uint16(0), uint16(0), uint16(1), uint16(1)),
+			nameRec(0x20, 1, "\x06", tArea3d(0, 0, 11, 0, 3)),
+			nameRec(0x20, 1, "\x07", tokens(tArea3d(0, 0, 0, 0, 0xFF), tArea3d(0, 0, 0xFFFF, 0, 0), pack(uint8(ptgUnion)))),
+		},
+		sheets: []testSheet{
+			{name: "Areas", records: []record{
+				numberRec(19, 5, 1),
+				// breaks before the rows 6, 11 and 16, the last one below the print area
+				rec(XLS_TYPE_HORIZONTALPAGEBREAKS, uint16(3), uint16(15), uint16(0), uint16(0xFF),
+					uint16(5), uint16(0), uint16(0xFF), uint16(10), uint16(0), uint16(0xFF)),
+				rec(XLS_TYPE_VERTICALPAGEBREAKS, uint16(1), uint16(2), uint16(0), uint16(0xFFFF)),
+			}},
+			{name: "Order", records: []record{
+				numberRec(3, 3, 1),
+				rec(XLS_TYPE_HORIZONTALPAGEBREAKS, uint16(1), uint16(2), uint16(0), uint16(0xFF)),
+				rec(XLS_TYPE_VERTICALPAGEBREAKS, uint16(1), uint16(2), uint16(0), uint16(0xFFFF)),
+				pageSetupRec(0x0001 | 0x0002),
+			}},
+		},
+	}
+	sheets := openTestBook(t, book).Sheets()
+
+	want := PrintLayout{
+		Areas:     []Range{{0, 11, 0, 3}},
+		TitleRows: &Range{0, 0, 0, 0xFF},
+		TitleCols: &Range{0, 0xFFFF, 0, 0},
+		RowBreaks: []int{5, 10, 15},
+		ColBreaks: []int{2},
+		// down, then over
+		Pages: []Range{{0, 4, 0, 1}, {5, 9, 0, 1}, {10, 11, 0, 1}, {0, 4, 2, 3}, {5, 9, 2, 3}, {10, 11, 2, 3}},
+	}
+	if got := sheets[0].PrintLayout(); !reflect.DeepEqual(got, want) {
+		t.Errorf("print area:\ngot  %+v\nwant %+v", got, want)
+	}
+
+	want = PrintLayout{
+		Areas:     []Range{{0, 3, 0, 3}},
+		RowBreaks: []int{2},
+		ColBreaks: []int{2},
+		// over, then down
+		Pages: []Range{{0, 1, 0, 1}, {0, 1, 2, 3}, {2, 3, 0, 1}, {2, 3, 2, 3}},
+	}
+	if got := sheets[1].PrintLayout(); !reflect.DeepEqual(got, want) {
+		t.Errorf("over then down:\ngot  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestPageBreaksBIFF5(t *testing.T) {
+	book := &testBook{biff5: true, sheets: []testSheet{{name: "S", records: []record{
+		labelRec5(9, 9, "x"),
+		rec(XLS_TYPE_HORIZONTALPAGEBREAKS, uint16(2), uint16(8), uint16(4)),
+		rec(XLS_TYPE_VERTICALPAGEBREAKS, uint16(1), uint16(5)),
+	}}}}
+	layout := openTestBook(t, book).Sheets()[0].PrintLayout()
+
+	if !reflect.DeepEqual(layout.RowBreaks, []int{4, 8}) || !reflect.DeepEqual(layout.ColBreaks, []int{5}) {
+		t.Errorf("got the row breaks %v and column breaks %v", layout.RowBreaks, layout.ColBreaks)
+	}
+	if len(layout.Pages) != 6 || layout.Pages[2] != (Range{8, 9, 0, 4}) {
+		t.Errorf("got the pages %v", layout.Pages)
+	}
+}
@@ -0,0 +1,23 @@
+package xls
+
+// Range is a rectangular block of cells. Coordinates are zero-based and inclusive.
+type Range struct {
+	FirstRow int
+	LastRow  int
+	FirstCol int
+	LastCol  int
+}
+
+// String returns the range in A1 notation, e.g. "B2:D10", or "B2" for a single cell.
+func (r Range) String() string {
+	first := formatCellAddress(r.FirstRow, r.FirstCol, true, true)
+	if r.FirstRow == r.LastRow && r.FirstCol == r.LastCol {
+		return first
+	}
+	return first + ":" + formatCellAddress(r.LastRow, r.LastCol, true, true)
+}
+
+// Contains reports whether the cell at row and col lies within the range.
+func (r Range) Contains(row, col int) bool {
+	return row >= r.FirstRow && row <= r.LastRow && col >= r.FirstCol && col <= r.LastCol
+}
@@ -0,0 +1,146 @@
+package xls
+
+import "unicode/utf16"
+
+// TextRun is a part of the text of a cell displayed in one font.
+type TextRun struct {
+	Text string
+	Font *Font
+}
+
+// formatRun gives the font used from a character position of a string on.
+type formatRun struct {
+	charPos   int
+	fontIndex int
+}
+
+// sstString is a string of the shared string table.
+type sstString struct {
+	value string
+
+	// formatting runs of rich text strings, ordered by character position
+	runs []formatRun
+
+	// phonetic reading of Asian text
+	phonetic *phoneticData
+}
+
+// textRuns splits the string into its formatting runs. Characters before the first run use cellFont.
+func (s *sstString) textRuns(xls *XLS, cellFont *Font) []TextRun {
+	return xls.textRuns(s.value, s.runs, cellFont)
+}
+
+// textRuns splits a text into its formatting runs, ordered by character position. Characters before
+// the first run use font. Character positions count UTF-16 code units, as stored in the file.
+func (xls *XLS) textRuns(text string, formatRuns []formatRun, font *Font) []TextRun {
+	chars := utf16.Encode([]rune(text))
+
+	var runs []TextRun
+	start := 0
+	for _, run := range formatRuns {
+		end := min(max(run.charPos, start), len(chars))
+		if end > start {
+			runs = append(runs, TextRun{Text: string(utf16.Decode(chars[start:end])), Font: font})
+		}
+		start, font = end, xls.font(run.fontIndex)
+	}
+	if start < len(chars) {
+		runs = append(runs, TextRun{Text: string(utf16.Decode(chars[start:])), Font: font})
+	}
+	return runs
+}
+
+const (
+	PhoneticKatakanaNarrow = 0x00
+	PhoneticKatakana       = 0x01
+	PhoneticHiragana       = 0x02
+	PhoneticAny            = 0x03
+)
+
+const (
+	PhoneticAlignGeneral     = 0x00
+	PhoneticAlignLeft        = 0x01
+	PhoneticAlignCenter      = 0x02
+	PhoneticAlignDistributed = 0x03
+)
+
+// Phonetic is the phonetic reading (furigana) of the text of a cell, used by Excel to sort Japanese text.
+type Phonetic struct {
+	// Text is the phonetic text of the whole cell text
+	Text string
+
+	Type      int // PhoneticKatakanaNarrow, PhoneticKatakana, PhoneticHiragana or PhoneticAny
+	Alignment int // PhoneticAlignGeneral, PhoneticAlignLeft, ...
+	Font      *Font
+
+	Runs []PhoneticRun
+}
+
+// PhoneticRun tells which part of the phonetic text is the reading of which characters of the cell text.
+// Positions count UTF-16 code units, as stored in the file.
+type PhoneticRun struct {
+	// PhoneticStart is the first character of the reading in the phonetic text
+	PhoneticStart int
+	// BaseStart and BaseLength give the characters of the cell text
+	BaseStart  int
+	BaseLength int
+}
+
+// phoneticData is the phonetic reading of a string of the shared string table.
+type phoneticData struct {
+	text      string
+	typ       int
+	alignment int
+	fontIndex int
+	runs      []PhoneticRun
+}
+
+// readPhonetic reads the Asian phonetic settings (ExtRst) of a string, nil if they are malformed.
+func readPhonetic(data []byte) *phoneticData {
+	// offset: 0; size: 2; reserved
+	// offset: 2; size: 2; size of the following data
+	if len(data) < 14 || 4+int(getUInt2d(data, 2)) > len(data) {
+		return nil
+	}
+
+	p := &phoneticData{}
+
+	// offset: 4; size: 2; index to FONT record of the phonetic text
+	p.fontIndex = int(getUInt2d(data, 4))
+
+	// offset: 6; size: 2; phonetic settings
+	// bit: 1-0; mask 0x0003; character type
+	// bit: 3-2; mask 0x000C; alignment
+	settings := getUInt2d(data, 6)
+	p.typ = int(settings & 0x0003)
+	p.alignment = int(settings&0x000C) >> 2
+
+	// offset: 8; size: 2; number of phonetic runs
+	crun := int(getUInt2d(data, 8))
+	// offset: 10; size: 2; number of characters of the phonetic text
+	// offset: 12; size: 2; number of characters of the phonetic text, repeated
+	cch := int(getUInt2d(data, 12))
+
+	// offset: 14; size: 2 * cch; phonetic text, always uncompressed
+	pos := 14
+	if pos+2*cch > len(data) {
+		return nil
+	}
+	chars := make([]uint16, cch)
+	for i := range chars {
+		chars[i] = getUInt2d(data, pos+2*i)
+	}
+	p.text = string(utf16.Decode(chars))
+	pos += 2 * cch
+
+	// offset: var; size: 6 * crun; phonetic runs
+	for i := 0; i < crun && pos+6 <= len(data); i++ {
+		p.runs = append(p.runs, PhoneticRun{
+			PhoneticStart: int(getUInt2d(data, pos)),
+			BaseStart:     int(getUInt2d(data, pos+2)),
+			BaseLength:    int(getUInt2d(data, pos+4)),
+		})
+		pos += 6
+	}
+	return p
+}
@@ -0,0 +1,124 @@
+package xls
+
+import (
+	"reflect"
+	"testing"
+	"unicode/utf16"
+)
+
+// sstEntry is a string of the SST record with 16-bit characters, its formatting runs (character position
+// and font index) and its Asian phonetic settings when ext is not nil.
+func sstEntry(s string, runs [][2]int, ext []byte) []byte {
+	flags := uint8(0x01)
+	header := []interface{}{uint16(len(utf16.Encode([]rune(s)))), flags}
+	if runs != nil {
+		header[1] = flags | 0x08
+		header = append(header, uint16(len(runs)))
+	}
+	if ext != nil {
+		header[1] = header[1].(uint8) | 0x04
+		header = append(header, uint32(len(ext)))
+	}
+	data := pack(append(header, utf16Bytes(s))...)
+	for _, run := range runs {
+		data = append(data, pack(uint16(run[0]), uint16(run[1]))...)
+	}
+	return append(data, ext...)
+}
+
+// sstBook has the fonts F0 to F5 and a sheet with the strings of the SST record in the cells A1, A2, ...
+func sstBook(entries ...[]byte) *testBook {
+	var fonts []record
+	for _, name := range []string{"F0", "F1", "F2", "F3", "F5"} {
+		fonts = append(fonts, fontRec(0x7FFF, name))
+	}
+	var cells []record
+	for i := range entries {
+		cells = append(cells, rec(XLS_TYPE_LABELSST, i, 0, 0, uint32(i)))
+	}
+	sst := pack(uint32(len(entries)), uint32(len(entries)))
+	for _, entry := range entries {
+		sst = append(sst, entry...)
+	}
+	return &testBook{
+		globals: append(fonts,
+			rec(XLS_TYPE_XF, uint16(1), uint16(0), uint16(0), make([]byte, 14)),
+			rec(XLS_TYPE_SST, sst)),
+		sheets: []testSheet{{name: "S", records: cells}},
+	}
+}
+
+func TestRichText(t *testing.T) {
+	book := sstBook(
+		// the emoji counts as two UTF-16 code units
+		sstEntry("a😀bc", [][2]int{{1, 2}, {3, 5}}, nil),
+		sstEntry("xyz", [][2]int{{0, 3}, {2, 2}, {10, 5}}, nil),
+		sstEntry("plain", nil, nil),
+	)
+	sheet := openTestBook(t, book).Sheets()[0]
+
+	runs := func(row int) [][2]string {
+		var got [][2]string
+		for _, run := range sheet.Row(row).Cell(0).RichText() {
+			name := ""
+			if run.Font != nil {
+				name = run.Font.Name
+			}
+			got = append(got, [2]string{run.Text, name})
+		}
+		return got
+	}
+	if got, want := runs(0), [][2]string{{"a", "F1"}, {"😀", "F2"}, {"bc", "F5"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("A1: got %q, want %q", got, want)
+	}
+	if got, want := runs(1), [][2]string{{"xy", "F3"}, {"z", "F2"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("A2: got %q, want %q", got, want)
+	}
+	if got := sheet.Row(2).Cell(0).RichText(); got != nil {
+		t.Errorf("A3: got %v", got)
+	}
+	if got := sheet.Row(0).Cell(0).Value(); got != "a😀bc" {
+		t.Errorf("A1 value: got %q", got)
+	}
+}
+
+// extRst is the Asian phonetic settings block of an SST string.
+func extRst(fontIndex, settings int, text string, runs ...PhoneticRun) []byte {
+	chars := utf16.Encode([]rune(text))
+	body := pack(uint16(fontIndex), uint16(settings), uint16(len(runs)), uint16(len(chars)), uint16(len(chars)), utf16Bytes(text))
+	for _, run := range runs {
+		body = append(body, pack(uint16(run.PhoneticStart), uint16(run.BaseStart), uint16(run.BaseLength))...)
+	}
+	return pack(uint16(1), uint16(len(body)), body)
+}
+
+func TestPhonetic(t *testing.T) {
+	runs := []PhoneticRun{{0, 0, 2}, {4, 2, 1}}
+	malformed := extRst(1, 0, "とうきょう")
+	malformed[2] = 0xFF // size past the end of the block
+	book := sstBook(
+		sstEntry("東京都", nil, extRst(2, PhoneticHiragana|PhoneticAlignCenter<<2, "とうきょうと", runs...)),
+		sstEntry("東京", [][2]int{{1, 3}}, malformed),
+		sstEntry("next", nil, nil),
+	)
+	sheet := openTestBook(t, book).Sheets()[0]
+
+	want := &Phonetic{Text: "とうきょうと", Type: PhoneticHiragana, Alignment: PhoneticAlignCenter, Runs: runs}
+	got := sheet.Row(0).Cell(0).Phonetic()
+	if got == nil || got.Font == nil || got.Font.Name != "F2" {
+		t.Fatalf("A1: got %+v", got)
+	}
+	got.Font = nil
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("A1: got %+v, want %+v", got, want)
+	}
+
+	// the strings after a malformed block are still read
+	cell := sheet.Row(1).Cell(0)
+	if cell.Phonetic() != nil || cell.Value() != "東京" || len(cell.RichText()) != 2 {
+		t.Errorf("A2: got %+v, %q, %v", cell.Phonetic(), cell.Value(), cell.RichText())
+	}
+	if got := sheet.Row(2).Cell(0); got.Value() != "next" || got.Phonetic() != nil {
+		t.Errorf("A3: got %q, %+v", got.Value(), got.Phonetic())
+	}
+}
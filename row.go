@@ -2,6 +2,23 @@ package xls
 
 type Row struct {
 	cells map[int]*Cell
+
+	// sheet and index locate the layout of the row
+	sheet *Sheet
+	index int
+}
+
+// rowInfo is a ROW record, the layout of a row.
+type rowInfo struct {
+	// height is the row height in twips (1/20 of a point)
+	height       int
+	customHeight bool
+	hidden       bool
+	outlineLevel int
+	collapsed    bool
+
+	// xfIndex is the index of the XF record of the row, -1 for rows without formatting
+	xfIndex int
 }
 
 func (r *Row) Cell(index int) *Cell {
@@ -15,6 +32,48 @@ func (r *Row) Cols() int {
 	return len(r.cells)
 }
 
+// Height returns the height of the row in points.
+func (r *Row) Height() float64 {
+	return float64(r.info().height) / 20
+}
+
+// CustomHeight reports whether the row height was set manually instead of following the font size.
+func (r *Row) CustomHeight() bool {
+	return r.info().customHeight
+}
+
+// Hidden reports whether the row is hidden.
+func (r *Row) Hidden() bool {
+	return r.info().hidden
+}
+
+// OutlineLevel returns the outline (grouping) level of the row from 0 to 7.
+func (r *Row) OutlineLevel() int {
+	return r.info().outlineLevel
+}
+
+// Collapsed reports whether the row follows a collapsed group of rows.
+func (r *Row) Collapsed() bool {
+	return r.info().collapsed
+}
+
+// XFIndex returns the index of the XF record used for the empty cells of the row,
+// 15 (the default cell format) for rows without formatting.
+func (r *Row) XFIndex() int {
+	if xfIndex := r.info().xfIndex; xfIndex >= 0 {
+		return xfIndex
+	}
+	return 15
+}
+
+// info returns the ROW record of the row, or the default row of the sheet.
+func (r *Row) info() *rowInfo {
+	if r.sheet == nil {
+		return &rowInfo{height: 255, xfIndex: -1}
+	}
+	return r.sheet.rowInfo(r.index)
+}
+
 func (r *Row) getCell(col int) *Cell {
 	if c, ok := r.cells[col]; ok {
 		return c
@@ -0,0 +1,42 @@
+package xls
+
+import "testing"
+
+func rowRec(row, height int, options uint32) record {
+	return rec(XLS_TYPE_ROW, row, 0, 1, height, 0, 0, options|0x100)
+}
+
+func TestRowLayout(t *testing.T) {
+	book := &testBook{sheets: []testSheet{{name: "S", records: []record{
+		rec(XLS_TYPE_DEFAULTROWHEIGHT, uint16(0), uint16(300)),
+		// default height flag, the height field is ignored
+		rowRec(0, 0x8000|400, 0),
+		// custom height, hidden and formatted with XF 20
+		rowRec(1, 400, 0x40|0x20|0x80|20<<16),
+		rowRec(2, 260, 0x01|0x10),
+		numberRec(3, 0, 1),
+	}}}}
+	sheet := openTestBook(t, book).Sheets()[0]
+
+	cases := []struct {
+		height       float64
+		customHeight bool
+		hidden       bool
+		outlineLevel int
+		collapsed    bool
+		xfIndex      int
+	}{
+		{15, false, false, 0, false, 15},
+		{20, true, true, 0, false, 20},
+		{13, false, false, 1, true, 15},
+		{15, false, false, 0, false, 15}, // no ROW record
+	}
+	for i, c := range cases {
+		r := sheet.Row(i)
+		if r.Height() != c.height || r.CustomHeight() != c.customHeight || r.Hidden() != c.hidden ||
+			r.OutlineLevel() != c.outlineLevel || r.Collapsed() != c.collapsed || r.XFIndex() != c.xfIndex {
+			t.Errorf("row %d: got %v %v %v %v %v %v, want %+v", i+1, r.Height(), r.CustomHeight(), r.Hidden(),
+				r.OutlineLevel(), r.Collapsed(), r.XFIndex(), c)
+		}
+	}
+}
@@ -14,6 +14,41 @@ type Sheet struct {
 
 	rows map[int]*Row
 
+	// merged cell ranges from MERGEDCELLS records
+	mergedCells []Range
+	// hyperlinks from HYPERLINK and HLINKTOOLTIP records
+	hyperlinks []Hyperlink
+	// comments from NOTE, OBJ and TXO records
+	comments []Comment
+	// data validation rules from DATAVALIDATION records
+	dataValidations []DataValidation
+
+	// column layout from COLINFO records, default width from DEFCOLWIDTH (characters)
+	// and STANDARDWIDTH (1/256 of the digit width, -1 when missing)
+	colInfos      []colInfo
+	defColWidth   int
+	standardWidth int
+
+	// row layout from ROW records, and the default row from DEFAULTROWHEIGHT
+	rowInfos   map[int]*rowInfo
+	defaultRow rowInfo
+
+	// outline settings from the GUTS and WSBOOL records
+	maxRowLevel        int
+	maxColLevel        int
+	summaryBelow       bool
+	summaryRight       bool
+	showOutlineSymbols bool
+
+	// print setup from the PAGESETUP, margin, HEADER, FOOTER and print option records
+	pageSetup PageSetup
+	// manual page breaks from HORIZONTALPAGEBREAKS and VERTICALPAGEBREAKS
+	rowBreaks []int
+	colBreaks []int
+
+	// workbook the sheet belongs to
+	xls *XLS
+
 	maxRow int
 	maxCol int
 }
@@ -54,7 +89,94 @@ func (s *Sheet) Cols() int {
 	return s.maxCol + 1
 }
 
-func (s *Sheet) setValue(row, col int, value interface{}, dataType CellDataType) {
+// MergedCells returns the merged cell ranges of the sheet. The value of a merged area is stored in its top-left cell.
+func (s *Sheet) MergedCells() []Range {
+	return append([]Range(nil), s.mergedCells...)
+}
+
+// MergedValue returns the value and type of the cell at row and col like Cell.Value and Cell.DataType; cells
+// of a merged area give the value of its top-left cell, so that each row of a table holds all its values.
+// The cells themselves are not changed, formulas see the value only in the top-left cell as in Excel.
+func (s *Sheet) MergedValue(row, col int) (interface{}, CellDataType) {
+	c := s.cell(row, col)
+	if c == nil {
+		return nil, ""
+	}
+	if c.mergeRange != nil && !c.mergeAnchor {
+		if anchor := s.cell(c.mergeRange.FirstRow, c.mergeRange.FirstCol); anchor != nil {
+			c = anchor
+		}
+	}
+	return c.value, c.dataType
+}
+
+// markMergedCells links the cells of merged areas to their range, adding the missing cells. Areas are
+// cut at the last used row and column.
+func (s *Sheet) markMergedCells() {
+	for i := range s.mergedCells {
+		rng := &s.mergedCells[i]
+		if rng.FirstRow > s.maxRow || rng.FirstCol > s.maxCol {
+			continue
+		}
+		anchor := s.getRow(rng.FirstRow).getCell(rng.FirstCol)
+		s.eachCell(rng, func(c *Cell) {
+			c.mergeRange = rng
+			c.mergeAnchor = c == anchor
+		})
+	}
+}
+
+// Hyperlinks returns the hyperlinks of the sheet in file order.
+func (s *Sheet) Hyperlinks() []Hyperlink {
+	return append([]Hyperlink(nil), s.hyperlinks...)
+}
+
+// Comments returns the cell comments of the sheet, including those of cells outside the used range.
+func (s *Sheet) Comments() []Comment {
+	return append([]Comment(nil), s.comments...)
+}
+
+// DataValidations returns the data validation rules of the sheet with the cell ranges they apply to.
+func (s *Sheet) DataValidations() []DataValidation {
+	return append([]DataValidation(nil), s.dataValidations...)
+}
+
+// PageSetup returns the print setup of the sheet.
+func (s *Sheet) PageSetup() PageSetup {
+	return s.pageSetup
+}
+
+// markHyperlinks links the cells of the sheet to their hyperlink, adding the missing cells within the used range.
+func (s *Sheet) markHyperlinks() {
+	for i := range s.hyperlinks {
+		link := &s.hyperlinks[i]
+		s.eachCell(&link.Range, func(c *Cell) {
+			c.hyperlink = link
+		})
+	}
+}
+
+// eachCell calls fn for the cells of a range, cut at the last used row and column, adding the missing cells.
+func (s *Sheet) eachCell(rng *Range, fn func(c *Cell)) {
+	for row := rng.FirstRow; row <= min(rng.LastRow, s.maxRow); row++ {
+		r := s.getRow(row)
+		for col := rng.FirstCol; col <= min(rng.LastCol, s.maxCol); col++ {
+			c := r.getCell(col)
+			c.xls = s.xls
+			fn(c)
+		}
+	}
+}
+
+// rowInfo returns the layout of a row from its ROW record, or the default row.
+func (s *Sheet) rowInfo(row int) *rowInfo {
+	if info, ok := s.rowInfos[row]; ok {
+		return info
+	}
+	return &s.defaultRow
+}
+
+func (s *Sheet) setValue(row, col int, value interface{}, dataType CellDataType) *Cell {
 	s.maxRow = max(s.maxRow, row)
 	s.maxCol = max(s.maxCol, col)
 
@@ -62,13 +184,23 @@ func (s *Sheet) setValue(row, col int, value interface{}, dataType CellDataType)
 	c := r.getCell(col)
 
 	c.setValue(value, dataType)
+	c.xls = s.xls
+	return c
+}
+
+// cell returns the cell at row and col, or nil if it was not stored. Unlike Row it never adds rows.
+func (s *Sheet) cell(row, col int) *Cell {
+	if r, ok := s.rows[row]; ok {
+		return r.Cell(col)
+	}
+	return nil
 }
 
 func (s *Sheet) getRow(row int) *Row {
 	if r, ok := s.rows[row]; ok {
 		return r
 	}
-	r := new(Row)
+	r := &Row{sheet: s, index: row}
 	r.cells = make(map[int]*Cell)
 	for i := 0; i <= s.maxCol; i++ {
 		r.cells[i] = new(Cell)
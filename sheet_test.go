@@ -0,0 +1,40 @@
+package xls
+
+import "testing"
+
+func TestMergedCells(t *testing.T) {
+	book := &testBook{sheets: []testSheet{{name: "S", records: []record{
+		labelRec(0, 0, "title"),
+		numberRec(3, 3, 1),
+		rec(XLS_TYPE_MERGEDCELLS, uint16(3), uint16(0), uint16(1), uint16(0), uint16(2), uint16(2), uint16(3),
+			uint16(1), uint16(1), uint16(100), uint16(200), uint16(0), uint16(0)),
+	}}}}
+	sheet := openTestBook(t, book).Sheets()[0]
+
+	if merged := sheet.MergedCells(); len(merged) != 3 || merged[0].String() != "A1:C2" {
+		t.Fatalf("got %v", merged)
+	}
+	if rng, anchor, ok := sheet.Row(0).Cell(0).MergeRange(); !ok || !anchor || rng.String() != "A1:C2" {
+		t.Errorf("A1: got %v, %v, %v", rng, anchor, ok)
+	}
+	covered := sheet.Row(1).Cell(2)
+	if _, anchor, ok := covered.MergeRange(); !ok || anchor || covered.Value() != nil {
+		t.Errorf("C2: got %v, %v, %#v", anchor, ok, covered.Value())
+	}
+	if _, _, ok := sheet.Row(3).Cell(3).MergeRange(); ok {
+		t.Error("D4 is merged")
+	}
+
+	if value, dataType := sheet.MergedValue(1, 2); value != "title" || dataType != CellDataTypeString {
+		t.Errorf("MergedValue(C2): got %#v, %q", value, dataType)
+	}
+	if covered.Value() != nil {
+		t.Errorf("C2 changed to %#v", covered.Value())
+	}
+	if value, _ := sheet.MergedValue(2, 1); value != nil {
+		t.Errorf("MergedValue(B3): got %#v", value)
+	}
+	if value, dataType := sheet.MergedValue(50, 50); value != nil || dataType != "" {
+		t.Errorf("MergedValue outside the sheet: got %#v, %q", value, dataType)
+	}
+}
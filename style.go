@@ -0,0 +1,221 @@
+package xls
+
+const (
+	AlignHorizontalGeneral               = 0x00
+	AlignHorizontalLeft                  = 0x01
+	AlignHorizontalCenter                = 0x02
+	AlignHorizontalRight                 = 0x03
+	AlignHorizontalFill                  = 0x04
+	AlignHorizontalJustify               = 0x05
+	AlignHorizontalCenterAcrossSelection = 0x06
+	AlignHorizontalDistributed           = 0x07
+)
+
+const (
+	AlignVerticalTop         = 0x00
+	AlignVerticalCenter      = 0x01
+	AlignVerticalBottom      = 0x02
+	AlignVerticalJustify     = 0x03
+	AlignVerticalDistributed = 0x04
+)
+
+const (
+	BorderNone             = 0x00
+	BorderThin             = 0x01
+	BorderMedium           = 0x02
+	BorderDashed           = 0x03
+	BorderDotted           = 0x04
+	BorderThick            = 0x05
+	BorderDouble           = 0x06
+	BorderHair             = 0x07
+	BorderMediumDashed     = 0x08
+	BorderDashDot          = 0x09
+	BorderMediumDashDot    = 0x0A
+	BorderDashDotDot       = 0x0B
+	BorderMediumDashDotDot = 0x0C
+	BorderSlantDashDot     = 0x0D
+)
+
+const (
+	FillNone            = 0x00
+	FillSolid           = 0x01
+	FillMediumGray      = 0x02
+	FillDarkGray        = 0x03
+	FillLightGray       = 0x04
+	FillDarkHorizontal  = 0x05
+	FillDarkVertical    = 0x06
+	FillDarkDown        = 0x07
+	FillDarkUp          = 0x08
+	FillDarkGrid        = 0x09
+	FillDarkTrellis     = 0x0A
+	FillLightHorizontal = 0x0B
+	FillLightVertical   = 0x0C
+	FillLightDown       = 0x0D
+	FillLightUp         = 0x0E
+	FillLightGrid       = 0x0F
+	FillLightTrellis    = 0x10
+	FillGray125         = 0x11
+	FillGray0625        = 0x12
+)
+
+// Style is the formatting of a cell from its XF record, with the attributes
+// taken from the parent cell style resolved.
+type Style struct {
+	NumberFormat string
+	Font         *Font
+	Alignment    Alignment
+	Border       Border
+	Fill         Fill
+	Protection   Protection
+}
+
+type Alignment struct {
+	Horizontal int // AlignHorizontalGeneral, AlignHorizontalLeft, ...
+	Vertical   int // AlignVerticalTop, AlignVerticalCenter, ...
+
+	Wrap        bool
+	ShrinkToFit bool
+	// Indent is the indentation level
+	Indent int
+	// Rotation is the text rotation in degrees, from -90 (clockwise) to 90 (counterclockwise)
+	Rotation int
+	// Stacked is set for vertical text with the letters stacked top to bottom
+	Stacked bool
+}
+
+type Border struct {
+	Left, Right, Top, Bottom BorderLine
+
+	// Diagonal is the line of both diagonals, drawn when DiagonalDown (top left to bottom right)
+	// or DiagonalUp (bottom left to top right) are set
+	Diagonal     BorderLine
+	DiagonalDown bool
+	DiagonalUp   bool
+}
+
+type BorderLine struct {
+	Style int // BorderNone, BorderThin, ...
+	Color Color
+}
+
+const (
+	GradientLinear = 0x00
+	GradientPath   = 0x01
+)
+
+type Fill struct {
+	Pattern    int // FillNone, FillSolid, ...
+	Foreground Color
+	Background Color
+
+	// Gradient is the gradient fill set by Excel 2007 and later, nil for pattern fills
+	Gradient *Gradient
+}
+
+// Gradient is a gradient fill from an XFEXT record.
+type Gradient struct {
+	Type int // GradientLinear or GradientPath
+
+	// Degree is the angle of linear gradients
+	Degree float64
+	// Left, Right, Top and Bottom give the inner rectangle of path gradients, from 0 to 1
+	Left, Right, Top, Bottom float64
+
+	Stops []GradientStop
+}
+
+type GradientStop struct {
+	// Position is the position of the stop, from 0 to 1
+	Position float64
+	Color    Color
+}
+
+type Protection struct {
+	Locked bool
+	Hidden bool
+}
+
+// attributes of an XF record that a cell XF either defines or takes from its parent style XF
+const (
+	xfAttrNumberFormat = 0x04
+	xfAttrFont         = 0x08
+	xfAttrAlignment    = 0x10
+	xfAttrBorder       = 0x20
+	xfAttrFill         = 0x40
+	xfAttrProtection   = 0x80
+)
+
+// xf is an XF record: the formatting of a cell (cell XF) or of a named cell style (style XF).
+// Colors are palette indexes, they are resolved when the style is requested.
+type xf struct {
+	fontIndex   int
+	formatIndex int
+
+	// style is set for style XFs, parent is the style XF a cell XF is based on
+	style  bool
+	parent int
+
+	// attributes is the set of xfAttr flags; for cell XFs the attributes defined by this XF,
+	// for style XFs the attributes that are not used
+	attributes byte
+
+	alignment  Alignment
+	border     Border
+	fill       Fill
+	protection Protection
+
+	// fontColor is the text color of an XFEXT record replacing the color of the font
+	fontColor *Color
+}
+
+// attributeXf returns the XF providing an attribute of an XF: the XF itself, or its parent style XF
+// when a cell XF does not define the attribute. It returns nil for an unknown XF.
+func (xls *XLS) attributeXf(xfIndex int, attribute byte) *xf {
+	if xfIndex < 0 || xfIndex >= len(xls.xfs) {
+		return nil
+	}
+
+	x := xls.xfs[xfIndex]
+	if !x.style && x.attributes&attribute == 0 && x.parent < len(xls.xfs) && x.parent != xfIndex {
+		return xls.xfs[x.parent]
+	}
+	return x
+}
+
+// style returns the style of an XF record, nil for an unknown XF.
+func (xls *XLS) style(xfIndex int) *Style {
+	if xfIndex < 0 || xfIndex >= len(xls.xfs) {
+		return nil
+	}
+
+	fontXf := xls.attributeXf(xfIndex, xfAttrFont)
+	style := &Style{
+		NumberFormat: xls.numberFormat(xfIndex),
+		Font:         xls.font(fontXf.fontIndex),
+		Alignment:    xls.attributeXf(xfIndex, xfAttrAlignment).alignment,
+		Border:       xls.attributeXf(xfIndex, xfAttrBorder).border,
+		Fill:         xls.attributeXf(xfIndex, xfAttrFill).fill,
+		Protection:   xls.attributeXf(xfIndex, xfAttrProtection).protection,
+	}
+
+	if style.Font != nil && fontXf.fontColor != nil {
+		style.Font.Color = xls.resolve(*fontXf.fontColor)
+	}
+	for _, line := range []*BorderLine{&style.Border.Left, &style.Border.Right, &style.Border.Top,
+		&style.Border.Bottom, &style.Border.Diagonal} {
+		line.Color = xls.resolve(line.Color)
+	}
+	style.Fill.Foreground = xls.resolve(style.Fill.Foreground)
+	style.Fill.Background = xls.resolve(style.Fill.Background)
+	if g := style.Fill.Gradient; g != nil {
+		resolved := *g
+		resolved.Stops = make([]GradientStop, len(g.Stops))
+		for i, stop := range g.Stops {
+			stop.Color = xls.resolve(stop.Color)
+			resolved.Stops[i] = stop
+		}
+		style.Fill.Gradient = &resolved
+	}
+
+	return style
+}
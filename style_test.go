@@ -0,0 +1,89 @@
+package xls
+
+import "testing"
+
+func fontRec(color int, name string) record {
+	return rec(XLS_TYPE_FONT, uint16(200), uint16(0), uint16(color), uint16(400), uint16(0), uint8(0), uint8(2),
+		uint8(0), uint8(0), shortString(name))
+}
+
+// fullColor is a FullColorExt structure of an XFEXT record.
+func fullColor(typ int, tint int16, value uint32) []byte {
+	return pack(uint16(typ), tint, value, make([]byte, 8))
+}
+
+func xfExtProperty(typ int, data []byte) []byte {
+	return pack(uint16(typ), uint16(4+len(data)), data)
+}
+
+// styleBook has the cell A1 with the XF record 0, a solid fill and the XFEXT record given by its properties.
+func styleBook(globals []record, properties ...[]byte) *testBook {
+	xf := rec(XLS_TYPE_XF, uint16(0), uint16(0), uint16(0), uint8(0), uint8(0), uint8(0), uint8(0xFC),
+		uint32(1<<8), uint32(1<<26), uint16(0x0A))
+	xfExt := rec(XLS_TYPE_XFEXT, make([]byte, 12), uint16(0), uint16(0), uint16(0), uint16(len(properties)),
+		pack(bytesParts(properties)...))
+	return &testBook{
+		globals: append([]record{fontRec(0x7FFF, "Arial"), xf, xfExt}, globals...),
+		sheets:  []testSheet{{name: "S", records: []record{rec(XLS_TYPE_NUMBER, 0, 0, 0, 0.5)}}},
+	}
+}
+
+func bytesParts(parts [][]byte) []interface{} {
+	values := make([]interface{}, len(parts))
+	for i, p := range parts {
+		values[i] = p
+	}
+	return values
+}
+
+func TestXfExtColors(t *testing.T) {
+	gradient := pack(uint32(GradientLinear), 90.0, 0.0, 0.0, 0.0, 0.0, uint32(3),
+		uint16(2), uint32(0x0000FF), 0.0, 0.0,
+		uint16(0), uint32(0), 0.5, 0.0, // automatic color, left out
+		uint16(3), uint32(4), 1.0, -0.5)
+	book := styleBook(nil,
+		xfExtProperty(0x04, fullColor(2, 0, 0x00336699)),
+		xfExtProperty(0x07, fullColor(3, 16383, 4)),
+		xfExtProperty(0x0D, fullColor(3, 0, 5)),
+		xfExtProperty(0x06, gradient),
+		xfExtProperty(0x0F, pack(uint16(20))),
+	)
+	style := openTestBook(t, book).Sheets()[0].Row(0).Cell(0).Style()
+
+	if c := style.Fill.Foreground; c.Index != ColorIndexRGB || c.Hex() != "996633" {
+		t.Errorf("fill: got %+v", c)
+	}
+	if c := style.Border.Top.Color; c.Index != ColorIndexTheme || c.Theme != 4 || c.Hex() != "A7C0DE" {
+		t.Errorf("top border: got %+v %s", c, c.Hex())
+	}
+	if c := style.Font.Color; c.Theme != 5 || c.Hex() != "C0504D" {
+		t.Errorf("font: got %+v", c)
+	}
+	g := style.Fill.Gradient
+	if g == nil || g.Degree != 90 || len(g.Stops) != 2 {
+		t.Fatalf("gradient: got %+v", g)
+	}
+	if g.Stops[0].Color.Hex() != "FF0000" || g.Stops[1].Position != 1 || g.Stops[1].Color.Hex() != "254061" {
+		t.Errorf("gradient stops: got %+v", g.Stops)
+	}
+	if style.Alignment.Indent != 20 {
+		t.Errorf("indent: got %d", style.Alignment.Indent)
+	}
+}
+
+func TestXfExtPaletteColors(t *testing.T) {
+	// the PALETTE record follows the XFEXT record and changes color 8 to gray
+	palette := pack(uint16(56), []byte{0x80, 0x80, 0x80, 0}, make([]byte, 55*4))
+	book := styleBook([]record{rec(XLS_TYPE_PALETTE, palette)},
+		xfExtProperty(0x04, fullColor(1, -16384, 8)),
+		xfExtProperty(0x0D, fullColor(1, 0, 8)),
+	)
+	style := openTestBook(t, book).Sheets()[0].Row(0).Cell(0).Style()
+
+	if c := style.Fill.Foreground; c.Index != 8 || c.Tint >= 0 || c.Hex() != "404040" {
+		t.Errorf("fill: got %+v %s", c, c.Hex())
+	}
+	if c := style.Font.Color; c.Index != 8 || c.Hex() != "808080" {
+		t.Errorf("font: got %+v %s", c, c.Hex())
+	}
+}
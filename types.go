@@ -60,6 +60,8 @@ const XLS_TYPE_COLINFO = 0x007d
 
 //const XLS_TYPE_IMDATA = 0x007f
 
+const XLS_TYPE_GUTS = 0x0080
+
 const XLS_TYPE_SHEETPR = 0x0081
 
 const XLS_TYPE_HCENTER = 0x0083
@@ -70,6 +72,8 @@ const XLS_TYPE_SHEET = 0x0085
 
 const XLS_TYPE_PALETTE = 0x0092
 
+const XLS_TYPE_STANDARDWIDTH = 0x0099
+
 const XLS_TYPE_SCL = 0x00a0
 
 const XLS_TYPE_PAGESETUP = 0x00a1
@@ -114,13 +118,13 @@ const XLS_TYPE_LABEL = 0x0204
 
 const XLS_TYPE_BOOLERR = 0x0205
 
-//const XLS_TYPE_STRING = 0x0207
+const XLS_TYPE_STRING = 0x0207
 
 const XLS_TYPE_ROW = 0x0208
 
 //const XLS_TYPE_INDEX = 0x020b
 
-//const XLS_TYPE_ARRAY = 0x0221
+const XLS_TYPE_ARRAY = 0x0221
 
 const XLS_TYPE_DEFAULTROWHEIGHT = 0x0225
 
@@ -134,6 +138,8 @@ const XLS_TYPE_FORMAT = 0x041e
 
 const XLS_TYPE_SHAREDFMLA = 0x04bc
 
+const XLS_TYPE_HLINKTOOLTIP = 0x0800
+
 const XLS_TYPE_BOF = 0x0809
 
 const XLS_TYPE_SHEETPROTECTION = 0x0867
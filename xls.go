@@ -2,6 +2,10 @@ package xls
 
 import (
 	"golang.org/x/text/encoding"
+	"io"
+	"io/fs"
+	"strconv"
+	"strings"
 	"unicode/utf16"
 )
 
@@ -34,87 +38,168 @@ type XLS struct {
 
 	version int
 
+	// dates are counted from 1904-01-01 instead of 1900-01-01
+	date1904 bool
+
 	sheets []*Sheet
 
-	sst []string
+	sst []*sstString
+
+	// number formats from FORMAT records by format index, and the XF records
+	formats map[int]string
+	xfs     []*xf
+
+	// fonts from FONT records, with unresolved colors, and the colors of the PALETTE record
+	fonts   []*Font
+	palette [][3]uint8
+
+	// external references and names used to resolve formulas
+	externalBooks []*externalBook
+	externSheets  []externSheet
+	definedNames  []*definedName
+
+	// formula cell waiting for its string result in the following STRING record
+	pendingString *Cell
+
+	// shared and array formulas of the current sheet keyed by their top-left cell, and the cells using them
+	sharedFormulas map[[2]int]*sharedFormula
+	expCells       []expCell
+
+	// comments of the current sheet: NOTE records, and TXO texts keyed by the id of the preceding OBJ record
+	notes       []note
+	textObjects map[int]*textObject
+	objID       int
 }
 
+// Open reads the workbook stored in the named file.
 func Open(filename string) (*XLS, error) {
 	ole, err := readOLE(filename)
 	if err != nil {
 		return nil, err
 	}
 
-	xls := &XLS{ole: ole, data: ole.getStream(ole.wrkbook), CodePage: DefaultCodePage}
+	return parse(ole)
+}
+
+// OpenReader reads the workbook from the first size bytes of r.
+func OpenReader(r io.ReaderAt, size int64) (*XLS, error) {
+	ole, err := readOLEReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	return parse(ole)
+}
+
+// OpenBytes reads the workbook from an in-memory copy of the file.
+// The slice is retained by the returned XLS and must not be modified.
+func OpenBytes(data []byte) (*XLS, error) {
+	ole, err := newOLE(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return parse(ole)
+}
+
+// OpenFS reads the named workbook from fsys, e.g. an embed.FS.
+func OpenFS(fsys fs.FS, name string) (*XLS, error) {
+	ole, err := readOLEFS(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return parse(ole)
+}
+
+func parse(ole *OLE) (*XLS, error) {
+	data, err := ole.getStream(ole.wrkbook)
+	if err != nil {
+		return nil, err
+	}
+
+	xls := &XLS{ole: ole, data: data, CodePage: DefaultCodePage}
 
-	xls.setDocumentSummaryInformation(ole.getStream(ole.documentSummaryInformation))
+	summary, err := ole.getStream(ole.documentSummaryInformation)
+	if err != nil {
+		return nil, err
+	}
+	xls.setDocumentSummaryInformation(summary)
 
 	xls.dataSize = len(xls.data)
 	xls.pos = 0
 
-	xls.sst = []string{}
+	xls.sst = []*sstString{}
+	xls.formats = make(map[int]string)
 
 external1:
 	for xls.pos < xls.dataSize {
+		offset := xls.pos
 		code := getUInt2d(xls.data, xls.pos)
 		switch code {
 		case XLS_TYPE_BOF:
-			xls.readBof() // <- implemented
+			err = xls.readBof() // <- implemented
 			break
 		case XLS_TYPE_FILEPASS:
-			xls.readDefault()
+			// the rest of the stream is encrypted and would only decode to garbage
+			err = ErrEncrypted
 			break
 		case XLS_TYPE_CODEPAGE:
 			//xls.CodePage = parseCodePage(getUInt2d(xls.data, xls.pos+4))
-			xls.readDefault()
+			err = xls.readDefault()
 			break
 		case XLS_TYPE_DATEMODE:
-			xls.readDefault()
+			err = xls.readDateMode() // <- implemented
 			break
 		case XLS_TYPE_FONT:
-			xls.readDefault()
+			err = xls.readFont() // <- implemented
 			break
 		case XLS_TYPE_FORMAT:
-			xls.readDefault()
+			err = xls.readFormat() // <- implemented
 			break
 		case XLS_TYPE_XF:
-			xls.readDefault()
+			err = xls.readXf() // <- implemented
 			break
 		case XLS_TYPE_XFEXT:
-			xls.readDefault()
+			err = xls.readXfExt() // <- implemented
 			break
 		case XLS_TYPE_STYLE:
-			xls.readDefault()
+			err = xls.readDefault()
 			break
 		case XLS_TYPE_PALETTE:
-			xls.readDefault()
+			err = xls.readPalette() // <- implemented
 			break
 		case XLS_TYPE_SHEET:
-			xls.readSheet() // <- implemented
+			err = xls.readSheet() // <- implemented
 			break
 		case XLS_TYPE_EXTERNALBOOK:
-			xls.readDefault()
+			err = xls.readExternalBook() // <- implemented
 			break
 		case XLS_TYPE_EXTERNNAME:
-			xls.readDefault()
+			err = xls.readExternName() // <- implemented
 			break
 		case XLS_TYPE_EXTERNSHEET:
-			xls.readDefault()
+			err = xls.readExternSheet() // <- implemented
 			break
 		case XLS_TYPE_DEFINEDNAME:
-			xls.readDefault()
+			err = xls.readDefinedName() // <- implemented
 			break
 		case XLS_TYPE_MSODRAWINGGROUP:
-			xls.readDefault()
+			err = xls.readDefault()
 			break
 		case XLS_TYPE_SST:
-			xls.readSst() // <- implemented
+			err = xls.readSst() // <- implemented
 			break
 		case XLS_TYPE_EOF:
-			xls.readDefault()
+			if err = xls.readDefault(); err != nil {
+				return nil, newRecordError(code, offset, err)
+			}
 			break external1
 		default:
-			xls.readDefault()
+			err = xls.readDefault()
+		}
+		if err != nil {
+			return nil, newRecordError(code, offset, err)
 		}
 	}
 
@@ -125,171 +210,202 @@ external1:
 		}
 
 		xls.pos = sheet.offset
+		xls.sharedFormulas = make(map[[2]int]*sharedFormula)
+		xls.expCells = nil
+		xls.notes = nil
+		xls.textObjects = make(map[int]*textObject)
+		xls.objID = -1
 
 	external2:
 		for xls.pos < xls.dataSize-4 {
+			offset := xls.pos
 			code := getUInt2d(xls.data, xls.pos)
 			switch code {
 			case XLS_TYPE_BOF:
-				xls.readDefault()
+				err = xls.readDefault()
 				break
 			case XLS_TYPE_PRINTGRIDLINES:
-				xls.readDefault()
+				err = xls.readPrintFlag(&sheet.pageSetup.PrintGridlines) // <- implemented
 				break
 			case XLS_TYPE_DEFAULTROWHEIGHT:
-				xls.readDefault()
+				err = xls.readDefaultRowHeight(sheet) // <- implemented
+				break
+			case XLS_TYPE_GUTS:
+				err = xls.readGuts(sheet) // <- implemented
 				break
 			case XLS_TYPE_SHEETPR:
-				xls.readDefault()
+				err = xls.readSheetPr(sheet) // <- implemented
 				break
 			case XLS_TYPE_HORIZONTALPAGEBREAKS:
-				xls.readDefault()
+				err = xls.readPageBreaks(&sheet.rowBreaks) // <- implemented
 				break
 			case XLS_TYPE_VERTICALPAGEBREAKS:
-				xls.readDefault()
+				err = xls.readPageBreaks(&sheet.colBreaks) // <- implemented
 				break
 			case XLS_TYPE_HEADER:
-				xls.readDefault()
+				err = xls.readHeaderFooter(&sheet.pageSetup.Header) // <- implemented
 				break
 			case XLS_TYPE_FOOTER:
-				xls.readDefault()
+				err = xls.readHeaderFooter(&sheet.pageSetup.Footer) // <- implemented
 				break
 			case XLS_TYPE_HCENTER:
-				xls.readDefault()
+				err = xls.readPrintFlag(&sheet.pageSetup.HorizontalCentered) // <- implemented
 				break
 			case XLS_TYPE_VCENTER:
-				xls.readDefault()
+				err = xls.readPrintFlag(&sheet.pageSetup.VerticalCentered) // <- implemented
 				break
 			case XLS_TYPE_LEFTMARGIN:
-				xls.readDefault()
+				err = xls.readMargin(&sheet.pageSetup.Margins.Left) // <- implemented
 				break
 			case XLS_TYPE_RIGHTMARGIN:
-				xls.readDefault()
+				err = xls.readMargin(&sheet.pageSetup.Margins.Right) // <- implemented
 				break
 			case XLS_TYPE_TOPMARGIN:
-				xls.readDefault()
+				err = xls.readMargin(&sheet.pageSetup.Margins.Top) // <- implemented
 				break
 			case XLS_TYPE_BOTTOMMARGIN:
-				xls.readDefault()
+				err = xls.readMargin(&sheet.pageSetup.Margins.Bottom) // <- implemented
 				break
 			case XLS_TYPE_PAGESETUP:
-				xls.readDefault()
+				err = xls.readPageSetup(sheet) // <- implemented
 				break
 			case XLS_TYPE_PROTECT:
-				xls.readDefault()
+				err = xls.readDefault()
 				break
 			case XLS_TYPE_SCENPROTECT:
-				xls.readDefault()
+				err = xls.readDefault()
 				break
 			case XLS_TYPE_OBJECTPROTECT:
-				xls.readDefault()
+				err = xls.readDefault()
 				break
 			case XLS_TYPE_PASSWORD:
-				xls.readDefault()
+				err = xls.readDefault()
 				break
 			case XLS_TYPE_DEFCOLWIDTH:
-				xls.readDefault()
+				err = xls.readDefColWidth(sheet) // <- implemented
+				break
+			case XLS_TYPE_STANDARDWIDTH:
+				err = xls.readStandardWidth(sheet) // <- implemented
 				break
 			case XLS_TYPE_COLINFO:
-				xls.readDefault()
+				err = xls.readColInfo(sheet) // <- implemented
 				break
 			case XLS_TYPE_DIMENSION:
-				xls.readDefault()
+				err = xls.readDefault()
 				break
 			case XLS_TYPE_ROW:
-				xls.readDefault()
+				err = xls.readRow(sheet) // <- implemented
 				break
 			case XLS_TYPE_DBCELL:
-				xls.readDefault()
+				err = xls.readDefault()
 				break
 			case XLS_TYPE_RK:
-				xls.readRK(sheet) // <- implemented
+				err = xls.readRK(sheet) // <- implemented
 				break
 			case XLS_TYPE_LABELSST:
-				xls.readLabelSst(sheet) // <- implemented
+				err = xls.readLabelSst(sheet) // <- implemented
 				break
 			case XLS_TYPE_MULRK:
-				xls.readDefault()
+				err = xls.readMulRK(sheet) // <- implemented
 				break
 			case XLS_TYPE_NUMBER:
-				xls.readNumber(sheet) // <- implemented
+				err = xls.readNumber(sheet) // <- implemented
 				break
 			case XLS_TYPE_FORMULA:
-				xls.readDefault()
+				err = xls.readFormula(sheet) // <- implemented
+				break
+			case XLS_TYPE_STRING:
+				err = xls.readString() // <- implemented
 				break
 			case XLS_TYPE_SHAREDFMLA:
-				xls.readDefault()
+				err = xls.readSharedFmla() // <- implemented
+				break
+			case XLS_TYPE_ARRAY:
+				err = xls.readArray() // <- implemented
 				break
 			case XLS_TYPE_BOOLERR:
-				xls.readBoolErr(sheet) // <- implemented
+				err = xls.readBoolErr(sheet) // <- implemented
 				break
 			case XLS_TYPE_MULBLANK:
-				xls.readDefault()
+				err = xls.readMulBlank(sheet) // <- implemented
 				break
 			case XLS_TYPE_LABEL:
-				xls.readLabel(sheet) // <- implemented
+				err = xls.readLabel(sheet) // <- implemented
 				break
 			case XLS_TYPE_BLANK:
-				xls.readDefault()
+				err = xls.readBlank(sheet) // <- implemented
 				break
 			case XLS_TYPE_MSODRAWING:
-				xls.readDefault()
+				err = xls.readDefault()
 				break
 			case XLS_TYPE_OBJ:
-				xls.readDefault()
+				err = xls.readObj() // <- implemented
 				break
 			case XLS_TYPE_WINDOW2:
-				xls.readDefault()
+				err = xls.readDefault()
 				break
 			case XLS_TYPE_PAGELAYOUTVIEW:
-				xls.readDefault()
+				err = xls.readDefault()
 				break
 			case XLS_TYPE_SCL:
-				xls.readDefault()
+				err = xls.readDefault()
 				break
 			case XLS_TYPE_PANE:
-				xls.readDefault()
+				err = xls.readDefault()
 				break
 			case XLS_TYPE_SELECTION:
-				xls.readDefault()
+				err = xls.readDefault()
 				break
 			case XLS_TYPE_MERGEDCELLS:
-				xls.readDefault()
+				err = xls.readMergedCells(sheet) // <- implemented
 				break
 			case XLS_TYPE_HYPERLINK:
-				xls.readDefault()
+				err = xls.readHyperlink(sheet) // <- implemented
+				break
+			case XLS_TYPE_HLINKTOOLTIP:
+				err = xls.readHlinkTooltip(sheet) // <- implemented
 				break
 			case XLS_TYPE_DATAVALIDATIONS:
-				xls.readDefault()
+				err = xls.readDefault()
 				break
 			case XLS_TYPE_DATAVALIDATION:
-				xls.readDefault()
+				err = xls.readDataValidation(sheet) // <- implemented
 				break
 			case XLS_TYPE_SHEETLAYOUT:
-				xls.readDefault()
+				err = xls.readDefault()
 				break
 			case XLS_TYPE_SHEETPROTECTION:
-				xls.readDefault()
+				err = xls.readDefault()
 				break
 			case XLS_TYPE_RANGEPROTECTION:
-				xls.readDefault()
+				err = xls.readDefault()
 				break
 			case XLS_TYPE_NOTE:
-				xls.readDefault()
+				err = xls.readNote() // <- implemented
 				break
 			case XLS_TYPE_TXO:
-				xls.readDefault()
+				err = xls.readTxo() // <- implemented
 				break
 			case XLS_TYPE_CONTINUE:
-				xls.readDefault()
+				err = xls.readDefault()
 				break
 			case XLS_TYPE_EOF:
-				xls.readDefault()
+				if err = xls.readDefault(); err != nil {
+					return nil, newRecordError(code, offset, err)
+				}
 				break external2
 			default:
-				xls.readDefault()
+				err = xls.readDefault()
+			}
+			if err != nil {
+				return nil, newRecordError(code, offset, err)
 			}
 		}
+
+		xls.resolveSharedFormulas()
+		sheet.markMergedCells()
+		sheet.markHyperlinks()
+		xls.resolveComments(sheet)
 	}
 
 	return xls, nil
@@ -303,7 +419,7 @@ func (xls *XLS) setDocumentSummaryInformation(data []byte) {
 	secOffset := getInt4d(data, 44)
 	countProperties := getInt4d(data, secOffset+4)
 
-	for i := 0; i < countProperties; i++ {
+	for i := 0; i < countProperties && secOffset+16+8*i <= len(data); i++ {
 		id := getInt4d(data, (secOffset+8)+(8*i))
 		offset := getInt4d(data, (secOffset+12)+(8*i))
 		typeID := getInt4d(data, secOffset+offset)
@@ -334,7 +450,9 @@ func (xls *XLS) setDocumentSummaryInformation(data []byte) {
 
 		switch id {
 		case 0x01:
-			xls.CodePage = parseCodePage(value.(uint16))
+			if codePage, ok := value.(uint16); ok {
+				xls.CodePage = parseCodePage(codePage)
+			}
 		case 0x02:
 			//ole.spreadsheet.Properties.Category = value.(string)
 			break
@@ -350,17 +468,432 @@ func (xls *XLS) setDocumentSummaryInformation(data []byte) {
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
-func (xls *XLS) readDefault() {
-	length := getUInt2d(xls.data, xls.pos+2)
-	xls.pos += 4 + int(length)
+func (xls *XLS) readDefault() error {
+	_, err := xls.nextRecord()
+	return err
 }
 
-func (xls *XLS) readBof() {
-	length := getUInt2d(xls.data, xls.pos+2)
-	recordData := xls.data[xls.pos+4 : xls.pos+4+int(length)]
+// nextRecord returns the data of the record at the stream pointer and moves the pointer past it.
+func (xls *XLS) nextRecord() ([]byte, error) {
+	if xls.pos < 0 || xls.pos+4 > xls.dataSize {
+		return nil, ErrTruncated
+	}
+
+	length := int(getUInt2d(xls.data, xls.pos+2))
+	if xls.pos+4+length > xls.dataSize {
+		return nil, ErrTruncated
+	}
+
+	recordData := xls.data[xls.pos+4 : xls.pos+4+length]
 
 	// move stream pointer to next record
-	xls.pos += 4 + int(length)
+	xls.pos += 4 + length
+
+	return recordData, nil
+}
+
+// readDateMode reads the DATEMODE record, which selects the date system of the workbook.
+func (xls *XLS) readDateMode() error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+	if len(recordData) < 2 {
+		return ErrTruncated
+	}
+
+	// offset: 0; size: 2; 0 = base 1900, 1 = base 1904
+	xls.date1904 = getUInt2d(recordData, 0) == 1
+
+	return nil
+}
+
+// readFont reads a FONT record.
+func (xls *XLS) readFont() error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+	if len(recordData) < 14 {
+		return ErrTruncated
+	}
+
+	font := &Font{}
+
+	// offset: 0; size: 2; height of the font (in twips = 1/20 of a point)
+	font.Size = float64(getUInt2d(recordData, 0)) / 20
+
+	// offset: 2; size: 2; option flags
+	flags := getUInt2d(recordData, 2)
+	// bit: 1; mask 0x0002; italic
+	font.Italic = flags&0x0002 != 0
+	// bit: 3; mask 0x0008; strikeout
+	font.Strikeout = flags&0x0008 != 0
+
+	// offset: 4; size: 2; colour index
+	font.Color = Color{Index: int(getUInt2d(recordData, 4))}
+
+	// offset: 6; size: 2; font weight
+	font.Weight = int(getUInt2d(recordData, 6))
+	font.Bold = font.Weight >= 700
+
+	// offset: 8; size: 2; escapement type
+	font.Script = int(getUInt2d(recordData, 8))
+
+	// offset: 10; size: 1; underline type
+	font.Underline = int(recordData[10])
+
+	// offset: 11; size: 1; font family
+	font.Family = int(recordData[11])
+
+	// offset: 12; size: 1; character set
+	font.Charset = int(recordData[12])
+
+	// offset: 13; size: 1; not used
+	// offset: 14; size: var; font name
+	var stringData *stringConvertion
+	if xls.version == XLS_BIFF8 {
+		stringData, err = xls.readUnicodeStringShort(recordData[14:])
+	} else { //if xls.version == XLS_BIFF7 {
+		stringData, err = xls.readByteStringShort(recordData[14:])
+	}
+	if err != nil {
+		return err
+	}
+	font.Name = stringData.value
+
+	xls.fonts = append(xls.fonts, font)
+	return nil
+}
+
+// readPalette reads a PALETTE record, the colors replacing the default palette from index 8.
+func (xls *XLS) readPalette() error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+	if len(recordData) < 2 {
+		return ErrTruncated
+	}
+
+	// offset: 0; size: 2; number of following colors
+	nm := int(getUInt2d(recordData, 0))
+	if 2+4*nm > len(recordData) {
+		return ErrTruncated
+	}
+
+	// list of RGB colors
+	xls.palette = make([][3]uint8, 0, nm)
+	for i := 0; i < nm; i++ {
+		rgb := recordData[2+4*i : 2+4*i+3]
+		xls.palette = append(xls.palette, [3]uint8{rgb[0], rgb[1], rgb[2]})
+	}
+	return nil
+}
+
+// readFormat reads a FORMAT record, a number format code and its index.
+func (xls *XLS) readFormat() error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+	if len(recordData) < 2 {
+		return ErrTruncated
+	}
+
+	// offset: 0; size: 2; index used by XF records
+	indexCode := int(getUInt2d(recordData, 0))
+
+	// offset: 2; size: var; number format string
+	var stringData *stringConvertion
+	if xls.version == XLS_BIFF8 {
+		stringData, err = xls.readUnicodeStringLong(recordData[2:])
+	} else { //if xls.version == XLS_BIFF7 {
+		stringData, err = xls.readByteStringShort(recordData[2:])
+	}
+	if err != nil {
+		return err
+	}
+
+	xls.formats[indexCode] = stringData.value
+	return nil
+}
+
+// readXf reads an XF record. XF records are referenced by their position, starting at 0.
+func (xls *XLS) readXf() error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+	if (xls.version == XLS_BIFF8 && len(recordData) < 20) || len(recordData) < 16 {
+		return ErrTruncated
+	}
+
+	x := &xf{}
+
+	// offset: 0; size: 2; index to FONT record
+	x.fontIndex = int(getUInt2d(recordData, 0))
+
+	// offset: 2; size: 2; index to FORMAT record
+	x.formatIndex = int(getUInt2d(recordData, 2))
+
+	// offset: 4; size: 2; XF type, cell protection, and parent style XF
+	flags := getUInt2d(recordData, 4)
+	// bit 0; mask 0x0001; 1 = cell is locked
+	x.protection.Locked = flags&0x0001 != 0
+	// bit 1; mask 0x0002; 1 = formula is hidden
+	x.protection.Hidden = flags&0x0002 != 0
+	// bit 2; mask 0x0004; 0 = cell XF, 1 = style XF
+	x.style = flags&0x0004 != 0
+	// bit 4-15; mask 0xFFF0; index to parent style XF (always 0xFFF in style XFs)
+	x.parent = int(flags >> 4)
+
+	// offset: 6; size: 1; alignment and text break
+	// bit 2-0, mask 0x07; horizontal alignment
+	x.alignment.Horizontal = int(recordData[6] & 0x07)
+	// bit 3, mask 0x08; wrap text
+	x.alignment.Wrap = recordData[6]&0x08 != 0
+	// bit 6-4, mask 0x70; vertical alignment
+	x.alignment.Vertical = int(recordData[6]&0x70) >> 4
+
+	if xls.version == XLS_BIFF8 {
+		// offset: 7; size: 1; XF_ROTATION: Text rotation angle
+		switch rotation := int(recordData[7]); {
+		case rotation <= 90:
+			x.alignment.Rotation = rotation
+		case rotation <= 180:
+			x.alignment.Rotation = 90 - rotation
+		case rotation == 255:
+			x.alignment.Stacked = true
+		}
+
+		// offset: 8; size: 1; Indentation, shrink to cell size, and text direction
+		// bit: 3-0; mask: 0x0F; indent level
+		x.alignment.Indent = int(recordData[8] & 0x0F)
+		// bit: 4; mask: 0x10; 1 = shrink content to fit into cell
+		x.alignment.ShrinkToFit = recordData[8]&0x10 != 0
+		// bit: 7-6; mask: 0xC0; text direction, not used
+
+		// offset: 9; size: 1; flags for used attribute groups
+		x.attributes = recordData[9] & 0xFC
+
+		// offset: 10; size: 4; cell border lines and background area
+		borderAndBackground := uint32(getInt4d(recordData, 10))
+		// bit: 3-0; mask: 0x0000000F; left style
+		x.border.Left.Style = int(borderAndBackground & 0x0000000F)
+		// bit: 7-4; mask: 0x000000F0; right style
+		x.border.Right.Style = int(borderAndBackground&0x000000F0) >> 4
+		// bit: 11-8; mask: 0x00000F00; top style
+		x.border.Top.Style = int(borderAndBackground&0x00000F00) >> 8
+		// bit: 15-12; mask: 0x0000F000; bottom style
+		x.border.Bottom.Style = int(borderAndBackground&0x0000F000) >> 12
+		// bit: 22-16; mask: 0x007F0000; left color
+		x.border.Left.Color.Index = int(borderAndBackground&0x007F0000) >> 16
+		// bit: 29-23; mask: 0x3F800000; right color
+		x.border.Right.Color.Index = int(borderAndBackground&0x3F800000) >> 23
+		// bit: 30; mask: 0x40000000; 1 = diagonal line from top left to right bottom
+		x.border.DiagonalDown = borderAndBackground&0x40000000 != 0
+		// bit: 31; mask: 0x80000000; 1 = diagonal line from bottom left to top right
+		x.border.DiagonalUp = borderAndBackground&0x80000000 != 0
+
+		// offset: 14; size: 4;
+		borderAndBackground = uint32(getInt4d(recordData, 14))
+		// bit: 6-0; mask: 0x0000007F; top color
+		x.border.Top.Color.Index = int(borderAndBackground & 0x0000007F)
+		// bit: 13-7; mask: 0x00003F80; bottom color
+		x.border.Bottom.Color.Index = int(borderAndBackground&0x00003F80) >> 7
+		// bit: 20-14; mask: 0x001FC000; diagonal color
+		x.border.Diagonal.Color.Index = int(borderAndBackground&0x001FC000) >> 14
+		// bit: 24-21; mask: 0x01E00000; diagonal style
+		x.border.Diagonal.Style = int(borderAndBackground&0x01E00000) >> 21
+		// bit: 31-26; mask: 0xFC000000 fill pattern
+		x.fill.Pattern = int(borderAndBackground&0xFC000000) >> 26
+
+		// offset: 18; size: 2; pattern and background colour
+		// bit: 6-0; mask: 0x007F; color index for pattern color
+		x.fill.Foreground.Index = int(getUInt2d(recordData, 18) & 0x007F)
+		// bit: 13-7; mask: 0x3F80; color index for pattern background
+		x.fill.Background.Index = int(getUInt2d(recordData, 18)&0x3F80) >> 7
+	} else { //if xls.version == XLS_BIFF7 {
+		// offset: 7; size: 1
+		// bit: 1-0; mask: 0x03; XF_ORIENTATION: Text orientation
+		switch recordData[7] & 0x03 {
+		case 1:
+			x.alignment.Stacked = true
+		case 2:
+			x.alignment.Rotation = 90
+		case 3:
+			x.alignment.Rotation = -90
+		}
+		// bit: 7-2; mask: 0xFC; flags for used attribute groups
+		x.attributes = recordData[7] & 0xFC
+
+		// offset: 8; size: 4; cell border lines and background area
+		borderAndBackground := uint32(getInt4d(recordData, 8))
+		// bit: 6-0; mask: 0x0000007F; color index for pattern color
+		x.fill.Foreground.Index = int(borderAndBackground & 0x0000007F)
+		// bit: 13-7; mask: 0x00003F80; color index for pattern background
+		x.fill.Background.Index = int(borderAndBackground&0x00003F80) >> 7
+		// bit: 21-16; mask: 0x003F0000; fill pattern
+		x.fill.Pattern = int(borderAndBackground&0x003F0000) >> 16
+		// bit: 24-22; mask: 0x01C00000; bottom line style
+		x.border.Bottom.Style = int(borderAndBackground&0x01C00000) >> 22
+		// bit: 31-25; mask: 0xFE000000; bottom line color
+		x.border.Bottom.Color.Index = int(borderAndBackground&0xFE000000) >> 25
+
+		// offset: 12; size: 4; cell border lines
+		borderLines := uint32(getInt4d(recordData, 12))
+		// bit: 2-0; mask: 0x00000007; top line style
+		x.border.Top.Style = int(borderLines & 0x00000007)
+		// bit: 5-3; mask: 0x00000038; left line style
+		x.border.Left.Style = int(borderLines&0x00000038) >> 3
+		// bit: 8-6; mask: 0x000001C0; right line style
+		x.border.Right.Style = int(borderLines&0x000001C0) >> 6
+		// bit: 15-9; mask: 0x0000FE00; top line color index
+		x.border.Top.Color.Index = int(borderLines&0x0000FE00) >> 9
+		// bit: 22-16; mask: 0x007F0000; left line color index
+		x.border.Left.Color.Index = int(borderLines&0x007F0000) >> 16
+		// bit: 29-23; mask: 0x3F800000; right line color index
+		x.border.Right.Color.Index = int(borderLines&0x3F800000) >> 23
+	}
+
+	xls.xfs = append(xls.xfs, x)
+	return nil
+}
+
+// readXfExt reads an XFEXT record, the colors and other formatting of an XF record
+// that cannot be expressed by BIFF8, written by Excel 2007 and later.
+func (xls *XLS) readXfExt() error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+	if len(recordData) < 20 {
+		return ErrTruncated
+	}
+
+	// offset: 0; size: 12; future record header
+	// offset: 12; size: 2; reserved
+	// offset: 14; size: 2; index to XF record
+	ixfe := int(getUInt2d(recordData, 14))
+	if ixfe >= len(xls.xfs) {
+		return nil
+	}
+	x := xls.xfs[ixfe]
+
+	// offset: 16; size: 2; reserved
+	// offset: 18; size: 2; number of extension properties
+	cexts := int(getUInt2d(recordData, 18))
+
+	// offset: 20; size: var; extension properties
+	pos := 20
+	for i := 0; i < cexts; i++ {
+		if pos+4 > len(recordData) {
+			return ErrTruncated
+		}
+		// offset: 0; size: 2; extension type
+		extType := getUInt2d(recordData, pos)
+		// offset: 2; size: 2; size of the property including this header
+		cb := int(getUInt2d(recordData, pos+2))
+		if cb < 4 || pos+cb > len(recordData) {
+			return ErrTruncated
+		}
+		// offset: 4; size: var; property data
+		extData := recordData[pos+4 : pos+cb]
+		pos += cb
+
+		switch extType {
+		case 0x0004: // cell interior foreground color
+			if c, ok := readFullColor(extData); ok {
+				x.fill.Foreground = c
+			}
+		case 0x0005: // cell interior background color
+			if c, ok := readFullColor(extData); ok {
+				x.fill.Background = c
+			}
+		case 0x0006: // gradient fill
+			x.fill.Gradient = readGradient(extData)
+		case 0x0007: // top border color
+			if c, ok := readFullColor(extData); ok {
+				x.border.Top.Color = c
+			}
+		case 0x0008: // bottom border color
+			if c, ok := readFullColor(extData); ok {
+				x.border.Bottom.Color = c
+			}
+		case 0x0009: // left border color
+			if c, ok := readFullColor(extData); ok {
+				x.border.Left.Color = c
+			}
+		case 0x000A: // right border color
+			if c, ok := readFullColor(extData); ok {
+				x.border.Right.Color = c
+			}
+		case 0x000B: // diagonal border color
+			if c, ok := readFullColor(extData); ok {
+				x.border.Diagonal.Color = c
+			}
+		case 0x000D: // text color
+			if c, ok := readFullColor(extData); ok {
+				x.fontColor = &c
+			}
+		case 0x000F: // indentation level
+			if len(extData) >= 2 {
+				x.alignment.Indent = int(getUInt2d(extData, 0))
+			}
+		}
+	}
+	return nil
+}
+
+// readGradient reads an XFExtGradient structure of an XFEXT record, nil if it is truncated.
+func readGradient(data []byte) *Gradient {
+	if len(data) < 48 {
+		return nil
+	}
+
+	g := &Gradient{}
+	// offset: 0; size: 4; gradient type
+	g.Type = int(getInt4d(data, 0))
+	// offset: 4; size: 8; angle of linear gradients
+	g.Degree = extractNumber(data[4:12])
+	// offset: 12; size: 32; inner rectangle of path gradients
+	g.Left = extractNumber(data[12:20])
+	g.Right = extractNumber(data[20:28])
+	g.Top = extractNumber(data[28:36])
+	g.Bottom = extractNumber(data[36:44])
+
+	// offset: 44; size: 4; number of gradient stops
+	count := int(getInt4d(data, 44))
+
+	// offset: 48; size: 22 * count; gradient stops
+	for i := 0; i < count && 48+22*(i+1) <= len(data); i++ {
+		stop := data[48+22*i:]
+		// offset: 0; size: 2; color type, offset: 2; size: 4; color value
+		color, ok := readFullColor(append([]byte{stop[0], stop[1], 0, 0}, stop[2:6]...))
+		if !ok {
+			// automatic or not set
+			continue
+		}
+		// offset: 6; size: 8; position
+		// offset: 14; size: 8; tint
+		color.Tint = extractNumber(stop[14:22])
+		g.Stops = append(g.Stops, GradientStop{
+			Position: extractNumber(stop[6:14]),
+			Color:    color,
+		})
+	}
+	return g
+}
+
+func (xls *XLS) readBof() error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+	if len(recordData) < 4 {
+		return ErrTruncated
+	}
 
 	// offset: 2; size: 2; type of the following data
 	substreamType := getUInt2d(recordData, 2)
@@ -370,7 +903,7 @@ func (xls *XLS) readBof() {
 		version := getUInt2d(recordData, 0)
 		if version != XLS_BIFF8 && version != XLS_BIFF7 {
 			//return errors.New("cannot read this Excel file. Version is too old")
-			return
+			return nil
 		}
 		xls.version = int(version)
 
@@ -382,21 +915,29 @@ func (xls *XLS) readBof() {
 		// substream, e.g. chart
 		// just skip the entire substream
 		for {
+			offset := xls.pos
 			code := getUInt2d(xls.data, xls.pos)
-			xls.readDefault()
+			if err := xls.readDefault(); err != nil {
+				return newRecordError(code, offset, err)
+			}
 			if code == XLS_TYPE_EOF || xls.pos >= xls.dataSize {
 				break
 			}
 		}
 	}
+
+	return nil
 }
 
-func (xls *XLS) readSst() {
+func (xls *XLS) readSst() error {
 	// offset within (spliced) record data
 	pos := 0
 
 	// get spliced record data
-	splicedRecordData := xls.getSplicedRecordData()
+	splicedRecordData, err := xls.getSplicedRecordData()
+	if err != nil {
+		return err
+	}
 
 	recordData := splicedRecordData.recordData
 	spliceOffsets := splicedRecordData.spliceOffsets
@@ -408,8 +949,17 @@ func (xls *XLS) readSst() {
 	nm := getInt4d(recordData, 4)
 	pos += 4
 
+	if len(recordData) < pos {
+		return ErrTruncated
+	}
+
 	// loop through the Unicode strings (16-bit length)
 	for i := 0; i < nm; i++ {
+		// every string starts with at least its length and option flags
+		if pos+3 > len(recordData) {
+			return ErrTruncated
+		}
+
 		// number of characters in the Unicode string
 		numChars := getUInt2d(recordData, pos)
 		pos += 2
@@ -439,6 +989,9 @@ func (xls *XLS) readSst() {
 			// size of Asian phonetic setting
 			extendedRunLength = getInt4d(recordData, pos)
 			pos += 4
+			if extendedRunLength < 0 {
+				return ErrCorrupt
+			}
 		}
 
 		// expected byte length of character array if not split
@@ -448,7 +1001,7 @@ func (xls *XLS) readSst() {
 		}
 
 		// look up limit position
-		var limitpos int
+		limitpos := -1
 		for _, spliceOffset := range spliceOffsets {
 			// it can happen that the string is empty, therefore we need
 			// <= and not just <
@@ -457,6 +1010,9 @@ func (xls *XLS) readSst() {
 				break
 			}
 		}
+		if limitpos == -1 {
+			return ErrTruncated
+		}
 
 		var retstr []byte
 		if pos+length <= limitpos {
@@ -478,12 +1034,16 @@ func (xls *XLS) readSst() {
 			// keep reading the characters
 			for charsLeft > 0 {
 				// look up next limit position, in case the string span more than one continue record
+				limitpos = -1
 				for _, spliceOffset := range spliceOffsets {
 					if pos < spliceOffset {
 						limitpos = spliceOffset
 						break
 					}
 				}
+				if limitpos == -1 {
+					return ErrTruncated
+				}
 
 				// repeated option flags
 				// OpenOffice.org documentation 5.21
@@ -527,49 +1087,83 @@ func (xls *XLS) readSst() {
 					isCompressed = false
 				}
 
+				if length <= 0 {
+					// the continuation holds no characters, the count cannot be satisfied
+					return ErrCorrupt
+				}
+
 				pos += length
 			}
 		}
 
 		// convert to UTF-8
-		retstrStr := xls.decodeCodepage(string(retstr))
+		var retstrStr string
+		if isCompressed {
+			retstrStr = xls.decodeCodepage(string(retstr))
+		} else {
+			// UTF-16LE, fragments of a split string are all uncompressed by now
+			utf16Data := make([]uint16, len(retstr)/2)
+			for j := range utf16Data {
+				utf16Data[j] = uint16(retstr[2*j]) | uint16(retstr[2*j+1])<<8
+			}
+			retstrStr = string(utf16.Decode(utf16Data))
+		}
 
 		// read additional Rich-Text information, if any
-		var fmtRuns []map[string]uint16
+		var fmtRuns []formatRun
 		if hasRichText {
+			if pos+int(formattingRuns)*4 > len(recordData) {
+				return ErrTruncated
+			}
 			// list of formatting runs
 			for j := 0; j < int(formattingRuns); j++ {
 				// first formatted character; zero-based
 				charPos := getUInt2d(recordData, pos+j*4)
 				// index to font record
 				fontIndex := getUInt2d(recordData, pos+2+j*4)
-				fmtRuns = append(fmtRuns, map[string]uint16{
-					"charPos":   charPos,
-					"fontIndex": fontIndex,
+				fmtRuns = append(fmtRuns, formatRun{
+					charPos:   int(charPos),
+					fontIndex: int(fontIndex),
 				})
 			}
 			pos += int(formattingRuns) * 4
 		}
 
 		// read additional Asian phonetics information, if any
+		var phonetic *phoneticData
 		if hasAsian {
+			if pos+extendedRunLength > len(recordData) {
+				return ErrTruncated
+			}
+			phonetic = readPhonetic(recordData[pos : pos+extendedRunLength])
 			pos += extendedRunLength
 		}
 
+		if pos > len(recordData) {
+			return ErrTruncated
+		}
+
 		// store the shared sting
-		xls.sst = append(xls.sst, retstrStr)
+		xls.sst = append(xls.sst, &sstString{value: retstrStr, runs: fmtRuns, phonetic: phonetic})
 	}
+
+	return nil
 }
 
-func (xls *XLS) readSheet() {
-	length := getUInt2d(xls.data, xls.pos+2)
-	recordData := xls.data[xls.pos+4 : xls.pos+4+int(length)]
+func (xls *XLS) readSheet() error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+	if len(recordData) < 6 {
+		return ErrTruncated
+	}
 
 	// offset: 0; size: 4; absolute stream position of the BOF record of the sheet
-	recOffset := getInt4d(xls.data, xls.pos+4)
-
-	// move stream pointer to next record
-	xls.pos += 4 + int(length)
+	recOffset := getInt4d(recordData, 0)
+	if recOffset < 0 || recOffset >= xls.dataSize {
+		return ErrCorrupt
+	}
 
 	// offset: 4; size: 1; sheet state
 	var sheetState int
@@ -591,10 +1185,16 @@ func (xls *XLS) readSheet() {
 	var recName string
 
 	if xls.version == XLS_BIFF8 {
-		stringData := xls.readUnicodeStringShort(recordData[6:])
+		stringData, err := xls.readUnicodeStringShort(recordData[6:])
+		if err != nil {
+			return err
+		}
 		recName = stringData.value
 	} else if xls.version == XLS_BIFF7 {
-		stringData := xls.readByteStringShort(recordData[6:])
+		stringData, err := xls.readByteStringShort(recordData[6:])
+		if err != nil {
+			return err
+		}
 		recName = stringData.value
 	}
 
@@ -604,56 +1204,176 @@ func (xls *XLS) readSheet() {
 		sheetState: sheetState,
 		sheetType:  sheetType,
 		rows:       make(map[int]*Row),
+		xls:        xls,
+
+		defColWidth:   8,
+		standardWidth: -1,
+
+		rowInfos:   make(map[int]*rowInfo),
+		defaultRow: rowInfo{height: 255, xfIndex: -1},
+
+		summaryBelow:       true,
+		summaryRight:       true,
+		showOutlineSymbols: true,
+
+		pageSetup: PageSetup{
+			Scale:   100,
+			Margins: Margins{Left: 0.75, Right: 0.75, Top: 1, Bottom: 1, Header: 0.5, Footer: 0.5},
+		},
 	})
+
+	return nil
 }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
-func (xls *XLS) readLabel(sheet *Sheet) {
-	recordData, row, col := xls.getRecord()
+func (xls *XLS) readLabel(sheet *Sheet) error {
+	recordData, row, col, err := xls.getRecord(6)
+	if err != nil {
+		return err
+	}
 
+	var stringData *stringConvertion
 	if xls.version == XLS_BIFF8 {
-		stringData := xls.readUnicodeStringLong(recordData[6:])
-		sheet.setValue(row, col, stringData.value, CellDataTypeString)
+		stringData, err = xls.readUnicodeStringLong(recordData[6:])
 	} else { //if xls.version == XLS_BIFF7 {
-		stringData := xls.readByteStringLong(recordData[6:])
-		sheet.setValue(row, col, stringData.value, CellDataTypeString)
+		stringData, err = xls.readByteStringLong(recordData[6:])
 	}
+	if err != nil {
+		return err
+	}
+
+	// offset: 4; size: 2; index to XF record
+	xfIndex := int(getUInt2d(recordData, 4))
+
+	sheet.setValue(row, col, stringData.value, CellDataTypeString).xfIndex = xfIndex
+	return nil
 }
 
-func (xls *XLS) readLabelSst(sheet *Sheet) {
-	recordData, row, col := xls.getRecord()
+func (xls *XLS) readLabelSst(sheet *Sheet) error {
+	recordData, row, col, err := xls.getRecord(10)
+	if err != nil {
+		return err
+	}
 
+	// offset: 4; size: 2; index to XF record
+	xfIndex := int(getUInt2d(recordData, 4))
+
+	// offset: 6; size: 4; index to SST record
 	index := getInt4d(recordData, 6)
 
 	if index < 0 || index >= len(xls.sst) {
-		return
+		return nil
 	}
 
-	if false {
-		// TODO: rich text
-	} else {
-		sheet.setValue(row, col, xls.sst[index], CellDataTypeString)
+	cell := sheet.setValue(row, col, xls.sst[index].value, CellDataTypeString)
+	cell.xfIndex = xfIndex
+	if xls.sst[index].runs != nil || xls.sst[index].phonetic != nil {
+		cell.shared = xls.sst[index]
 	}
+	return nil
 }
 
-func (xls *XLS) readNumber(sheet *Sheet) {
-	recordData, row, col := xls.getRecord()
+func (xls *XLS) readNumber(sheet *Sheet) error {
+	recordData, row, col, err := xls.getRecord(14)
+	if err != nil {
+		return err
+	}
 
+	// offset: 4; size: 2; index to XF record
+	xfIndex := int(getUInt2d(recordData, 4))
+
+	// offset: 6; size: 8; IEEE 754 floating-point value
 	numValue := extractNumber(recordData[6:14])
-	sheet.setValue(row, col, numValue, CellDataTypeNumeric)
+	sheet.setValue(row, col, numValue, CellDataTypeNumeric).xfIndex = xfIndex
+	return nil
 }
 
-func (xls *XLS) readRK(sheet *Sheet) {
-	recordData, row, col := xls.getRecord()
+func (xls *XLS) readRK(sheet *Sheet) error {
+	recordData, row, col, err := xls.getRecord(10)
+	if err != nil {
+		return err
+	}
+
+	// offset: 4; size: 2; index to XF record
+	xfIndex := int(getUInt2d(recordData, 4))
 
+	// offset: 6; size: 4; RK value
 	rknum := getInt4d(recordData, 6)
 	numValue := getIEEE754(rknum)
-	sheet.setValue(row, col, numValue, CellDataTypeNumeric)
+	sheet.setValue(row, col, numValue, CellDataTypeNumeric).xfIndex = xfIndex
+	return nil
+}
+
+// readMulRK reads a MULRK record, the RK values of a run of cells in one row.
+func (xls *XLS) readMulRK(sheet *Sheet) error {
+	recordData, row, colFirst, err := xls.getRecord(6)
+	if err != nil {
+		return err
+	}
+
+	// offset: var; size: 2; index to last column
+	colLast := int(getUInt2d(recordData, len(recordData)-2))
+	columns := colLast - colFirst + 1
+	if columns < 1 || 4+6*columns+2 > len(recordData) {
+		return ErrCorrupt
+	}
+
+	// offset: 4; size: 6 * columns; list of XF index and RK value pairs
+	for i := 0; i < columns; i++ {
+		offset := 4 + 6*i
+
+		xfIndex := int(getUInt2d(recordData, offset))
+		numValue := getIEEE754(getInt4d(recordData, offset+2))
+		sheet.setValue(row, colFirst+i, numValue, CellDataTypeNumeric).xfIndex = xfIndex
+	}
+	return nil
+}
+
+// readBlank reads a BLANK record, an empty cell that only carries formatting.
+func (xls *XLS) readBlank(sheet *Sheet) error {
+	recordData, row, col, err := xls.getRecord(6)
+	if err != nil {
+		return err
+	}
+
+	// offset: 4; size: 2; index to XF record
+	xfIndex := int(getUInt2d(recordData, 4))
+
+	sheet.setValue(row, col, nil, CellDataTypeNull).xfIndex = xfIndex
+	return nil
+}
+
+// readMulBlank reads a MULBLANK record, a run of empty cells in one row that only carry formatting.
+func (xls *XLS) readMulBlank(sheet *Sheet) error {
+	recordData, row, colFirst, err := xls.getRecord(6)
+	if err != nil {
+		return err
+	}
+
+	// offset: var; size: 2; index to last column
+	colLast := int(getUInt2d(recordData, len(recordData)-2))
+	columns := colLast - colFirst + 1
+	if columns < 1 || 4+2*columns+2 > len(recordData) {
+		return ErrCorrupt
+	}
+
+	// offset: 4; size: 2 * columns; list of XF indexes
+	for i := 0; i < columns; i++ {
+		xfIndex := int(getUInt2d(recordData, 4+2*i))
+		sheet.setValue(row, colFirst+i, nil, CellDataTypeNull).xfIndex = xfIndex
+	}
+	return nil
 }
 
-func (xls *XLS) readBoolErr(sheet *Sheet) {
-	recordData, row, col := xls.getRecord()
+func (xls *XLS) readBoolErr(sheet *Sheet) error {
+	recordData, row, col, err := xls.getRecord(8)
+	if err != nil {
+		return err
+	}
+
+	// offset: 4; size: 2; index to XF record
+	xfIndex := int(getUInt2d(recordData, 4))
 
 	// offset: 6; size: 1; the boolean value or error value
 	boolErr := recordData[6]
@@ -664,47 +1384,1208 @@ func (xls *XLS) readBoolErr(sheet *Sheet) {
 	switch isError {
 	case 0: // boolean
 		value = boolErr
-		sheet.setValue(row, col, value, CellDataTypeBool)
+		sheet.setValue(row, col, value, CellDataTypeBool).xfIndex = xfIndex
 		break
 	case 1: // error type
-		value = CheckErrorCode(ErrorCode(boolErr))
-		sheet.setValue(row, col, value, CellDataTypeError)
+		value = mapErrorCode(boolErr)
+		sheet.setValue(row, col, value, CellDataTypeError).xfIndex = xfIndex
 		break
 	}
+	return nil
 }
 
-func (xls *XLS) getRecord() ([]byte, int, int) {
-	length := int(getUInt2d(xls.data, xls.pos+2))
-	recordData := xls.data[xls.pos+4 : xls.pos+4+length]
-	row := getUInt2d(recordData, 0)
-	col := getUInt2d(recordData, 2)
-	xls.pos += 4 + length
-	return recordData, int(row), int(col)
-}
-
-////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func (xls *XLS) readFormula(sheet *Sheet) error {
+	recordData, row, col, err := xls.getRecord(22)
+	if err != nil {
+		return err
+	}
 
-func (xls *XLS) decodeCodepage(data string) string {
-	return ConvertFrom(data, xls.CodePage)
-}
+	xls.pendingString = nil
+
+	var cell *Cell
+
+	// offset: 6; size: 8; result of the formula
+	// a result with 0xFFFF in the two most significant bytes is not a number,
+	// byte 6 then tells which kind of value it is
+	if recordData[12] == 0xFF && recordData[13] == 0xFF {
+		switch recordData[6] {
+		case 0x00: // string, value is in the STRING record that follows
+			cell = sheet.setValue(row, col, "", CellDataTypeString)
+			xls.pendingString = cell
+		case 0x01: // boolean, value at offset 8
+			cell = sheet.setValue(row, col, recordData[8], CellDataTypeBool)
+		case 0x02: // error, code at offset 8
+			cell = sheet.setValue(row, col, mapErrorCode(recordData[8]), CellDataTypeError)
+		case 0x03: // empty string
+			cell = sheet.setValue(row, col, "", CellDataTypeString)
+		default:
+			// unknown result type, the cell keeps its format and formula without a value
+			cell = sheet.setValue(row, col, nil, CellDataTypeNull)
+		}
+	} else {
+		numValue := extractNumber(recordData[6:14])
+		cell = sheet.setValue(row, col, numValue, CellDataTypeNumeric)
+	}
 
-// readByteStringShort reads a byte string (8-bit string length) from the given data.
-func (xls *XLS) readByteStringShort(subData []byte) *stringConvertion {
-	// offset: 0; size: 1; length of the string (character count)
-	ln := int(subData[0])
+	// offset: 4; size: 2; index to XF record
+	cell.xfIndex = int(getUInt2d(recordData, 4))
 
-	// offset: 1: size: var; character array (8-bit characters)
-	value := xls.decodeCodepage(string(subData[1 : 1+ln]))
+	// offset: 14; size: 2; option flags
+	// offset: 16; size: 4; not used
+	// offset: 20; size: 2; size of the parsed expression
+	formulaSize := int(getUInt2d(recordData, 20))
+	if 22+formulaSize > len(recordData) {
+		return ErrTruncated
+	}
 
-	return &stringConvertion{
-		value: value,
-		size:  1 + ln,
+	// offset: 22; size: var; parsed expression, followed by additional data
+	// a formula this package cannot decode keeps its cached value only
+	tokens, err := xls.parseFormula(recordData[22:22+formulaSize], recordData[22+formulaSize:], row, col)
+	if err == nil {
+		cell.formula = tokens
+		if len(tokens) == 1 && tokens[0].id == ptgExp {
+			// member of a shared or array formula, defined by a record that may still follow
+			xls.expCells = append(xls.expCells, expCell{cell: cell, row: row, col: col})
+		}
 	}
+
+	return nil
 }
 
-func (xls *XLS) readByteStringLong(subData []byte) *stringConvertion {
+// readPageBreaks reads a HORIZONTALPAGEBREAKS or VERTICALPAGEBREAKS record, the manual page breaks
+// before rows or columns.
+func (xls *XLS) readPageBreaks(breaks *[]int) error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+	if len(recordData) < 2 {
+		return ErrTruncated
+	}
+
+	// offset: 0; size: 2; number of following page breaks
+	count := int(getUInt2d(recordData, 0))
+
+	// BIFF8: 6 bytes per break, index of the row or column and the range of columns or rows it applies to;
+	// BIFF7: 2 bytes per break, index of the row or column
+	size := 2
+	if xls.version == XLS_BIFF8 {
+		size = 6
+	}
+	if 2+size*count > len(recordData) {
+		return ErrTruncated
+	}
+
+	for i := 0; i < count; i++ {
+		// offset: var; size: 2; index of the first row or column below or right of the break
+		*breaks = append(*breaks, int(getUInt2d(recordData, 2+size*i)))
+	}
+	return nil
+}
+
+// readPageSetup reads the PAGESETUP record, the paper, scaling and orientation of printed pages.
+func (xls *XLS) readPageSetup(sheet *Sheet) error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+	if len(recordData) < 34 {
+		return ErrTruncated
+	}
+
+	ps := &sheet.pageSetup
+
+	// offset: 10; size: 2; option flags
+	options := getUInt2d(recordData, 10)
+	// bit: 0; mask: 0x0001; 0 = down then over, 1 = over then down
+	ps.OverThenDown = options&0x0001 != 0
+	// bit: 2; mask: 0x0004; 1 = paper size, scale, resolution, copies and orientation are not initialised
+	isNotInit := options&0x0004 != 0
+	// bit: 3; mask: 0x0008; 1 = black and white
+	ps.BlackAndWhite = options&0x0008 != 0
+	// bit: 4; mask: 0x0010; 1 = draft quality
+	ps.Draft = options&0x0010 != 0
+
+	if !isNotInit {
+		// offset: 0; size: 2; paper size
+		ps.PaperSize = int(getUInt2d(recordData, 0))
+		// offset: 2; size: 2; scaling factor in percent
+		if scale := int(getUInt2d(recordData, 2)); scale > 0 {
+			ps.Scale = scale
+		}
+
+		// bit: 6; mask: 0x0040; 1 = orientation is not set
+		// bit: 1; mask: 0x0002; 0 = landscape, 1 = portrait
+		switch {
+		case options&0x0040 != 0:
+			ps.Orientation = OrientationDefault
+		case options&0x0002 != 0:
+			ps.Orientation = OrientationPortrait
+		default:
+			ps.Orientation = OrientationLandscape
+		}
+	}
+
+	// offset: 4; size: 2; start page number
+	// bit: 7; mask: 0x0080; 1 = use the start page number
+	if options&0x0080 != 0 {
+		ps.FirstPageNumber = int(getUInt2d(recordData, 4))
+	}
+	// offset: 6; size: 2; fit to width, number of pages
+	ps.FitToWidth = int(getUInt2d(recordData, 6))
+	// offset: 8; size: 2; fit to height, number of pages
+	ps.FitToHeight = int(getUInt2d(recordData, 8))
+
+	// offset: 12; size: 2; print resolution
+	// offset: 14; size: 2; vertical print resolution
+	// offset: 16; size: 8; header margin (IEEE 754 floating-point value)
+	ps.Margins.Header = extractNumber(recordData[16:24])
+	// offset: 24; size: 8; footer margin (IEEE 754 floating-point value)
+	ps.Margins.Footer = extractNumber(recordData[24:32])
+	// offset: 32; size: 2; number of copies
+	return nil
+}
+
+// readMargin reads a LEFTMARGIN, RIGHTMARGIN, TOPMARGIN or BOTTOMMARGIN record, a page margin in inches.
+func (xls *XLS) readMargin(margin *float64) error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+	if len(recordData) < 8 {
+		return ErrTruncated
+	}
+
+	// offset: 0; size: 8; margin (IEEE 754 floating-point value)
+	*margin = extractNumber(recordData[0:8])
+	return nil
+}
+
+// readPrintFlag reads a HCENTER, VCENTER or PRINTGRIDLINES record, a print setting that is on or off.
+func (xls *XLS) readPrintFlag(flag *bool) error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+	if len(recordData) < 2 {
+		return ErrTruncated
+	}
+
+	// offset: 0; size: 2; 0 = off, 1 = on
+	*flag = getUInt2d(recordData, 0) != 0
+	return nil
+}
+
+// readHeaderFooter reads a HEADER or FOOTER record, the text printed at the top or bottom of each page.
+func (xls *XLS) readHeaderFooter(hf *HeaderFooter) error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+
+	// the record data can be empty even when the record exists
+	if len(recordData) == 0 {
+		return nil
+	}
+
+	// offset: 0; size: var; header or footer text
+	var str *stringConvertion
+	if xls.version == XLS_BIFF8 {
+		str, err = xls.readUnicodeStringLong(recordData)
+	} else {
+		str, err = xls.readByteStringShort(recordData)
+	}
+	if err != nil {
+		return err
+	}
+
+	*hf = parseHeaderFooter(str.value)
+	return nil
+}
+
+// readGuts reads the GUTS record, the size of the outline symbol areas and the number of outline levels.
+func (xls *XLS) readGuts(sheet *Sheet) error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+	if len(recordData) < 8 {
+		return ErrTruncated
+	}
+
+	// offset: 0; size: 2; width of the area to display row outlines (left of the sheet), in pixel
+	// offset: 2; size: 2; height of the area to display column outlines (above the sheet), in pixel
+	// offset: 4; size: 2; number of visible row outline levels (used row levels + 1; or 0, if not used)
+	sheet.maxRowLevel = max(int(getUInt2d(recordData, 4))-1, 0)
+	// offset: 6; size: 2; number of visible column outline levels (used column levels + 1; or 0, if not used)
+	sheet.maxColLevel = max(int(getUInt2d(recordData, 6))-1, 0)
+	return nil
+}
+
+// readSheetPr reads the SHEETPR (WSBOOL) record, additional sheet settings.
+func (xls *XLS) readSheetPr(sheet *Sheet) error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+	if len(recordData) < 2 {
+		return ErrTruncated
+	}
+
+	// offset: 0; size: 2; option flags
+	options := getUInt2d(recordData, 0)
+	// bit: 6; mask: 0x0040; 0 = summary rows above detail, 1 = below
+	sheet.summaryBelow = options&0x0040 != 0
+	// bit: 7; mask: 0x0080; 0 = summary columns left of detail, 1 = right
+	sheet.summaryRight = options&0x0080 != 0
+	// bit: 8; mask: 0x0100; 1 = fit to page when printing
+	sheet.pageSetup.FitToPage = options&0x0100 != 0
+	// bit: 10; mask: 0x0400; 1 = outline symbols are shown
+	sheet.showOutlineSymbols = options&0x0400 != 0
+	return nil
+}
+
+// readDefaultRowHeight reads the DEFAULTROWHEIGHT record, the layout of rows without a ROW record.
+func (xls *XLS) readDefaultRowHeight(sheet *Sheet) error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+	if len(recordData) < 4 {
+		return ErrTruncated
+	}
+
+	// offset: 0; size: 2; option flags
+	options := getUInt2d(recordData, 0)
+	// bit: 0; mask: 0x0001; 1 = row height and font height do not match
+	sheet.defaultRow.customHeight = options&0x0001 != 0
+	// bit: 1; mask: 0x0002; 1 = row is hidden
+	sheet.defaultRow.hidden = options&0x0002 != 0
+
+	// offset: 2; size: 2; default height for unused rows, in twips
+	sheet.defaultRow.height = int(getUInt2d(recordData, 2))
+	return nil
+}
+
+// readRow reads a ROW record, the height and formatting of a row.
+func (xls *XLS) readRow(sheet *Sheet) error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+	if len(recordData) < 16 {
+		return ErrTruncated
+	}
+
+	// offset: 0; size: 2; index of this row
+	row := int(getUInt2d(recordData, 0))
+	// offset: 2; size: 2; index to column of the first cell which is described by a cell record
+	// offset: 4; size: 2; index to column of the last cell which is described by a cell record, increased by 1
+	info := &rowInfo{xfIndex: -1}
+
+	// offset: 6; size: 2;
+	// bit: 14-0; mask: 0x7FFF; height of the row, in twips
+	// bit: 15: mask: 0x8000; 0 = row has custom height; 1 = row has default height
+	height := getUInt2d(recordData, 6)
+	info.height = int(height & 0x7FFF)
+	if height&0x8000 != 0 {
+		info.height = sheet.defaultRow.height
+	}
+
+	// offset: 8; size: 2; not used
+	// offset: 10; size: 2; not used in BIFF5-BIFF8
+	// offset: 12; size: 4; option flags and default row formatting
+	options := getInt4d(recordData, 12)
+	// bit: 2-0: mask: 0x00000007; outline level of the row
+	info.outlineLevel = options & 0x00000007
+	// bit: 4; mask: 0x00000010; 1 = outline group starts or ends here (and is collapsed)
+	info.collapsed = options&0x00000010 != 0
+	// bit: 5; mask: 0x00000020; 1 = row is hidden
+	info.hidden = options&0x00000020 != 0
+	// bit: 6; mask: 0x00000040; 1 = row height and default font height do not match
+	info.customHeight = options&0x00000040 != 0
+	// bit: 7; mask: 0x00000080; 1 = row has explicit format
+	// bit: 27-16; mask: 0x0FFF0000; only applies when hasExplicitFormat = 1; index to XF record
+	if options&0x00000080 != 0 {
+		info.xfIndex = (options & 0x0FFF0000) >> 16
+	}
+
+	sheet.rowInfos[row] = info
+	return nil
+}
+
+// readDefColWidth reads the DEFCOLWIDTH record, the default column width in characters without padding.
+func (xls *XLS) readDefColWidth(sheet *Sheet) error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+	if len(recordData) < 2 {
+		return ErrTruncated
+	}
+
+	// offset: 0; size: 2; default column width
+	sheet.defColWidth = int(getUInt2d(recordData, 0))
+	return nil
+}
+
+// readStandardWidth reads the STANDARDWIDTH record, the default column width in 1/256 of the digit width.
+// It takes precedence over DEFCOLWIDTH.
+func (xls *XLS) readStandardWidth(sheet *Sheet) error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+	if len(recordData) < 2 {
+		return ErrTruncated
+	}
+
+	// offset: 0; size: 2; default column width
+	sheet.standardWidth = int(getUInt2d(recordData, 0))
+	return nil
+}
+
+// readColInfo reads a COLINFO record, the width and formatting of a block of columns.
+func (xls *XLS) readColInfo(sheet *Sheet) error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+	if len(recordData) < 10 {
+		return ErrTruncated
+	}
+
+	info := colInfo{
+		// offset: 0; size: 2; index to first column in range
+		firstCol: int(getUInt2d(recordData, 0)),
+		// offset: 2; size: 2; index to last column in range
+		lastCol: int(getUInt2d(recordData, 2)),
+	}
+	if info.firstCol > info.lastCol {
+		return nil
+	}
+
+	// offset: 4; size: 2; width of the column in 1/256 of the width of the zero character
+	width := int(getUInt2d(recordData, 4))
+	info.column.Pixels = columnPixels(width)
+	info.column.Width = columnWidth(info.column.Pixels)
+
+	// offset: 6; size: 2; index to XF record for default column formatting
+	info.column.XFIndex = int(getUInt2d(recordData, 6))
+
+	// offset: 8; size: 2; option flags
+	options := getUInt2d(recordData, 8)
+	// bit: 0; mask: 0x0001; 1= columns are hidden
+	info.column.Hidden = options&0x0001 != 0
+	// bit: 10-8; mask: 0x0700; outline level of the columns (0 = no outline)
+	info.column.OutlineLevel = int(options&0x0700) >> 8
+	// bit: 12; mask: 0x1000; 1 = collapsed
+	info.column.Collapsed = options&0x1000 != 0
+
+	sheet.colInfos = append(sheet.colInfos, info)
+	return nil
+}
+
+// readMergedCells reads a MERGEDCELLS record, a list of merged cell ranges.
+func (xls *XLS) readMergedCells(sheet *Sheet) error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+	if len(recordData) < 2 {
+		return ErrTruncated
+	}
+
+	// offset: 0; size: 2; number of cell range addresses
+	cmcs := int(getUInt2d(recordData, 0))
+	if 2+8*cmcs > len(recordData) {
+		return ErrTruncated
+	}
+
+	// offset: 2; size: 8 * cmcs; cell range addresses
+	for i := 0; i < cmcs; i++ {
+		offset := 2 + 8*i
+		rng := Range{
+			FirstRow: int(getUInt2d(recordData, offset)),
+			LastRow:  int(getUInt2d(recordData, offset+2)),
+			FirstCol: int(getUInt2d(recordData, offset+4)),
+			LastCol:  int(getUInt2d(recordData, offset+6)),
+		}
+		if rng.FirstRow > rng.LastRow || rng.FirstCol > rng.LastCol {
+			continue
+		}
+		sheet.mergedCells = append(sheet.mergedCells, rng)
+	}
+	return nil
+}
+
+// readHyperlink reads a HYPERLINK record, the link of a block of cells.
+func (xls *XLS) readHyperlink(sheet *Sheet) error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+	if len(recordData) < 8 {
+		return ErrTruncated
+	}
+
+	// offset: 0; size: 8; cell range address of all cells containing this hyperlink
+	rng := Range{
+		FirstRow: int(getUInt2d(recordData, 0)),
+		LastRow:  int(getUInt2d(recordData, 2)),
+		FirstCol: int(getUInt2d(recordData, 4)),
+		LastCol:  int(getUInt2d(recordData, 6)),
+	}
+	if rng.FirstRow > rng.LastRow || rng.FirstCol > rng.LastCol {
+		return nil
+	}
+
+	// offset: 8; size: var; hyperlink object
+	link, err := xls.readHyperlinkData(recordData[8:])
+	if err != nil {
+		return err
+	}
+	link.Range = rng
+	sheet.hyperlinks = append(sheet.hyperlinks, *link)
+	return nil
+}
+
+// readHlinkTooltip reads a HLINKTOOLTIP record, the tooltip of the hyperlink of a block of cells.
+func (xls *XLS) readHlinkTooltip(sheet *Sheet) error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+	if len(recordData) < 10 {
+		return ErrTruncated
+	}
+
+	// offset: 0; size: 2; record type, always 0x0800
+	// offset: 2; size: 8; cell range address of the hyperlink
+	rng := Range{
+		FirstRow: int(getUInt2d(recordData, 2)),
+		LastRow:  int(getUInt2d(recordData, 4)),
+		FirstCol: int(getUInt2d(recordData, 6)),
+		LastCol:  int(getUInt2d(recordData, 8)),
+	}
+
+	// offset: 10; size: var; tooltip, zero-terminated 16-bit characters
+	// the record follows the HYPERLINK record it belongs to
+	for i := len(sheet.hyperlinks) - 1; i >= 0; i-- {
+		if sheet.hyperlinks[i].Range == rng {
+			sheet.hyperlinks[i].Tooltip = utf16String(recordData[10:])
+			break
+		}
+	}
+	return nil
+}
+
+// readDataValidation reads a DATAVALIDATION record, a data validation rule and the cells it applies to.
+func (xls *XLS) readDataValidation(sheet *Sheet) error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+	if len(recordData) < 4 {
+		return ErrTruncated
+	}
+
+	dv := DataValidation{}
+
+	// offset: 0; size: 4; option flags
+	options := getInt4d(recordData, 0)
+	// bit: 0-3; mask: 0x0000000F; type
+	dv.Type = options & 0x0000000F
+	// bit: 4-6; mask: 0x00000070; error style
+	dv.ErrorStyle = (options & 0x00000070) >> 4
+	// bit: 7; mask: 0x00000080; list of explicit values
+	explicitList := options&0x00000080 != 0
+	// bit: 8; mask: 0x00000100; empty cells allowed
+	dv.AllowBlank = options&0x00000100 != 0
+	// bit: 9; mask: 0x00000200; suppress the drop down arrow of lists
+	dv.ShowDropDown = options&0x00000200 == 0
+	// bit: 18; mask: 0x00040000; show the prompt box when the cell is selected
+	dv.ShowInputMessage = options&0x00040000 != 0
+	// bit: 19; mask: 0x00080000; show the error box when an invalid value is entered
+	dv.ShowErrorMessage = options&0x00080000 != 0
+	// bit: 20-23; mask: 0x00F00000; condition operator
+	dv.Operator = (options & 0x00F00000) >> 20
+
+	// offset: 4; size: var; title of the prompt box, title of the error box, text of the prompt box
+	// and text of the error box, a single zero character when empty
+	offset := 4
+	for _, text := range []*string{&dv.PromptTitle, &dv.ErrorTitle, &dv.Prompt, &dv.Error} {
+		str, err := xls.readUnicodeStringLong(recordData[offset:])
+		if err != nil {
+			return err
+		}
+		if str.value != "\x00" {
+			*text = str.value
+		}
+		offset += str.size
+	}
+
+	// offset: var; size: var; formula data of the two conditions: size of the parsed expression,
+	// 2 unused bytes and the parsed expression
+	var formulas [2][]byte
+	for i := range formulas {
+		if offset+4 > len(recordData) {
+			return ErrTruncated
+		}
+		size := int(getUInt2d(recordData, offset))
+		offset += 4
+		if offset+size > len(recordData) {
+			return ErrTruncated
+		}
+		formulas[i] = recordData[offset : offset+size]
+		offset += size
+	}
+
+	// offset: var; size: 2; number of cell range addresses
+	if offset+2 > len(recordData) {
+		return ErrTruncated
+	}
+	count := int(getUInt2d(recordData, offset))
+	offset += 2
+	if offset+8*count > len(recordData) {
+		return ErrTruncated
+	}
+	// offset: var; size: 8 * count; cell range addresses
+	for i := 0; i < count; i++ {
+		pos := offset + 8*i
+		dv.Ranges = append(dv.Ranges, Range{
+			FirstRow: int(getUInt2d(recordData, pos)),
+			LastRow:  int(getUInt2d(recordData, pos+2)),
+			FirstCol: int(getUInt2d(recordData, pos+4)),
+			LastCol:  int(getUInt2d(recordData, pos+6)),
+		})
+	}
+
+	// relative references of the formulas are based on the top-left cell of the first range
+	row, col := 0, 0
+	if len(dv.Ranges) > 0 {
+		row, col = dv.Ranges[0].FirstRow, dv.Ranges[0].FirstCol
+	}
+	for i, text := range []*string{&dv.Formula1, &dv.Formula2} {
+		if len(formulas[i]) == 0 {
+			continue
+		}
+		tokens, err := xls.parseFormula(formulas[i], nil, row, col)
+		if err != nil {
+			// a formula this package cannot decode is left empty
+			continue
+		}
+		if i == 0 && dv.Type == ValidationList && explicitList && len(tokens) == 1 && tokens[0].id == ptgStr {
+			// the items of explicit lists are separated by zero characters
+			value, _ := tokens[0].value.(string)
+			dv.List = strings.Split(value, "\x00")
+			*text = `"` + strings.ReplaceAll(strings.Join(dv.List, ","), `"`, `""`) + `"`
+			continue
+		}
+		if *text, err = decompileFormula(tokens); err != nil {
+			*text = ""
+		}
+	}
+
+	sheet.dataValidations = append(sheet.dataValidations, dv)
+	return nil
+}
+
+// readSharedFmla reads a SHAREDFMLA record, the formula shared by a block of cells filled from the first one.
+func (xls *XLS) readSharedFmla() error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+	if len(recordData) < 10 {
+		return ErrTruncated
+	}
+
+	// offset: 0; size: 6; cell range address of the area using the shared formula
+	// offset: 6; size: 1; not used
+	// offset: 7; size: 1; number of FORMULA records using the shared formula
+	// offset: 8; size: 2; size of the parsed expression
+	// offset: 10; size: var; parsed expression, followed by additional data
+	return xls.storeSharedFormula(recordData, 8, false)
+}
+
+// readArray reads an ARRAY record, the formula of an array formula entered into a block of cells.
+func (xls *XLS) readArray() error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+	if len(recordData) < 14 {
+		return ErrTruncated
+	}
+
+	// offset: 0; size: 6; cell range address of the array formula
+	// offset: 6; size: 2; option flags
+	// offset: 8; size: 4; not used
+	// offset: 12; size: 2; size of the parsed expression
+	// offset: 14; size: var; parsed expression, followed by additional data
+	return xls.storeSharedFormula(recordData, 12, true)
+}
+
+// storeSharedFormula keeps the formula of a SHAREDFMLA or ARRAY record for its member cells,
+// sizePos is the offset of the size of the parsed expression.
+func (xls *XLS) storeSharedFormula(recordData []byte, sizePos int, array bool) error {
+	if xls.version != XLS_BIFF8 {
+		return nil
+	}
+
+	formulaSize := int(getUInt2d(recordData, sizePos))
+	if sizePos+2+formulaSize > len(recordData) {
+		return ErrTruncated
+	}
+
+	rng := Range{
+		// offset: 0; size: 2; index to first row
+		FirstRow: int(getUInt2d(recordData, 0)),
+		// offset: 2; size: 2; index to last row
+		LastRow: int(getUInt2d(recordData, 2)),
+		// offset: 4; size: 1; index to first column
+		FirstCol: int(recordData[4]),
+		// offset: 5; size: 1; index to last column
+		LastCol: int(recordData[5]),
+	}
+
+	xls.sharedFormulas[[2]int{rng.FirstRow, rng.FirstCol}] = &sharedFormula{
+		rng:   rng,
+		array: array,
+		rgce:  recordData[sizePos+2 : sizePos+2+formulaSize],
+		extra: recordData[sizePos+2+formulaSize:],
+	}
+
+	return nil
+}
+
+// resolveSharedFormulas replaces the tExp tokens of the cells of the current sheet by the formula they refer to.
+// Shared formulas are relative to each member cell, array formulas are the same for all cells of the array.
+func (xls *XLS) resolveSharedFormulas() {
+	for _, exp := range xls.expCells {
+		master := exp.cell.formula[0]
+		shared, ok := xls.sharedFormulas[[2]int{master.row, master.col}]
+		if !ok || !shared.rng.Contains(exp.row, exp.col) {
+			continue
+		}
+
+		row, col := exp.row, exp.col
+		if shared.array {
+			row, col = shared.rng.FirstRow, shared.rng.FirstCol
+			rng := shared.rng
+			exp.cell.arrayRange = &rng
+		}
+
+		tokens, err := xls.parseFormula(shared.rgce, shared.extra, row, col)
+		if err != nil {
+			continue
+		}
+		exp.cell.formula = tokens
+	}
+
+	xls.sharedFormulas = nil
+	xls.expCells = nil
+}
+
+// readObj reads an OBJ record. Only the id of the object is used, to link the text of the
+// following TXO record to the NOTE record of a comment.
+func (xls *XLS) readObj() error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+	if xls.version != XLS_BIFF8 || len(recordData) < 8 {
+		return nil
+	}
+
+	// offset: 0; size: 2; ftCmo, type of the common object data subrecord (0x15)
+	// offset: 2; size: 2; size of the subrecord data
+	// offset: 4; size: 2; object type (0x19 = comment)
+	// offset: 6; size: 2; object id
+	if getUInt2d(recordData, 0) == 0x15 {
+		xls.objID = int(getUInt2d(recordData, 6))
+	}
+	return nil
+}
+
+// readTxo reads a TXO record, the text of a text box or comment, with the CONTINUE records holding
+// the text and its formatting runs.
+func (xls *XLS) readTxo() error {
+	spliced, err := xls.getSplicedRecordData()
+	if err != nil {
+		return err
+	}
+	data, spliceOffsets := spliced.recordData, spliced.spliceOffsets
+	if spliceOffsets[1] < 14 {
+		return ErrTruncated
+	}
+
+	// offset: 0; size: 2; option flags (alignment and lock)
+	// offset: 2; size: 2; rotation
+	// offset: 4; size: 6; not used
+	// offset: 10; size: 2; character count of the text, in the first CONTINUE records
+	cch := int(getUInt2d(data, 10))
+	// offset: 12; size: 2; size of the formatting runs, in the CONTINUE record following the text
+	cbRuns := int(getUInt2d(data, 12))
+
+	// the text may be split over several CONTINUE records, each starting with the option flags
+	// of its characters
+	chars := make([]uint16, 0, cch)
+	i := 1
+	for ; len(chars) < cch; i++ {
+		if i+1 >= len(spliceOffsets) {
+			return ErrTruncated
+		}
+		pos, end := spliceOffsets[i], spliceOffsets[i+1]
+		if pos >= end {
+			continue
+		}
+
+		// bit: 0; mask: 0x01; character compression (0 = compressed 8-bit, 1 = uncompressed 16-bit)
+		isCompressed := (data[pos] & 0x01) == 0
+		pos++
+		for len(chars) < cch && pos < end {
+			if isCompressed {
+				chars = append(chars, uint16(data[pos]))
+				pos++
+			} else if pos+2 <= end {
+				chars = append(chars, getUInt2d(data, pos))
+				pos += 2
+			} else {
+				break
+			}
+		}
+	}
+
+	txo := &textObject{text: string(utf16.Decode(chars))}
+
+	// formatting runs of 8 bytes: character position, font index and 4 unused bytes,
+	// the last one marking the end of the text
+	if i < len(spliceOffsets) {
+		pos := spliceOffsets[i]
+		for j := 0; j+8 <= cbRuns && pos+j+8 <= len(data); j += 8 {
+			txo.runs = append(txo.runs, formatRun{
+				charPos:   int(getUInt2d(data, pos+j)),
+				fontIndex: int(getUInt2d(data, pos+j+2)),
+			})
+		}
+	}
+
+	if xls.objID >= 0 {
+		xls.textObjects[xls.objID] = txo
+	}
+	return nil
+}
+
+// readNote reads a NOTE record, the comment of a cell. In BIFF8 the text is stored in the TXO record
+// of the comment object, in BIFF7 it follows in the NOTE record and may be continued by further NOTE records.
+func (xls *XLS) readNote() error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+	if len(recordData) < 6 {
+		return ErrTruncated
+	}
+
+	// offset: 0; size: 2; index to row
+	row := int(getUInt2d(recordData, 0))
+	// offset: 2; size: 2; index to column
+	col := int(getUInt2d(recordData, 2))
+
+	if xls.version == XLS_BIFF8 {
+		if len(recordData) < 8 {
+			return ErrTruncated
+		}
+
+		// offset: 4; size: 2; option flags
+		// bit: 1; mask: 0x0002; comment is always shown
+		visible := getUInt2d(recordData, 4)&0x0002 != 0
+		// offset: 6; size: 2; id of the OBJ record of the comment
+		objID := int(getUInt2d(recordData, 6))
+		// offset: 8; size: var; author, Unicode string, 16-bit string length
+		author, err := xls.readUnicodeStringLong(recordData[8:])
+		if err != nil {
+			return err
+		}
+
+		xls.notes = append(xls.notes, note{row: row, col: col, objID: objID, author: author.value, visible: visible})
+		return nil
+	}
+
+	// offset: 4; size: 2; character count of the whole text, of the text in this record for continuations;
+	// a record holds at most 2048 characters, the rest follows in records with row 0xFFFF
+	cch := min(int(getUInt2d(recordData, 4)), len(recordData)-6)
+	// offset: 6; size: var; text, 8-bit characters
+	text := xls.decodeCodepage(string(recordData[6 : 6+cch]))
+
+	if row == 0xFFFF && len(xls.notes) > 0 {
+		// continuation of the text of the previous note
+		xls.notes[len(xls.notes)-1].text += text
+		return nil
+	}
+	xls.notes = append(xls.notes, note{row: row, col: col, objID: -1, text: text})
+	return nil
+}
+
+// readString reads the STRING record holding the string result of the preceding FORMULA record.
+func (xls *XLS) readString() error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+
+	cell := xls.pendingString
+	xls.pendingString = nil
+	if cell == nil {
+		return nil
+	}
+
+	var stringData *stringConvertion
+	if xls.version == XLS_BIFF8 {
+		stringData, err = xls.readUnicodeStringLong(recordData)
+	} else {
+		stringData, err = xls.readByteStringLong(recordData)
+	}
+	if err != nil {
+		return err
+	}
+
+	cell.setValue(stringData.value, CellDataTypeString)
+	return nil
+}
+
+// readExternalBook reads an EXTERNALBOOK (SUPBOOK) record. It describes the workbook itself,
+// an add-in or an external workbook that the following EXTERNNAME records and EXTERNSHEET entries refer to.
+func (xls *XLS) readExternalBook() error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+	if len(recordData) < 4 {
+		return ErrTruncated
+	}
+
+	book := &externalBook{}
+
+	// offset: 0; size: 2; number of sheets, or 0x0001 for add-ins
+	numSheets := int(getUInt2d(recordData, 0))
+
+	switch {
+	case len(recordData) == 4 && recordData[2] == 0x01 && recordData[3] == 0x04:
+		// internal reference
+		book.typ = ExternalBookInternal
+	case len(recordData) == 4 && recordData[2] == 0x01 && recordData[3] == 0x3A:
+		// add-in functions
+		book.typ = ExternalBookAddIn
+	default:
+		// offset: 2; size: var; encoded URL without sheet name (Unicode string, 16-bit length)
+		stringData, err := xls.readUnicodeStringLong(recordData[2:])
+		if err != nil {
+			return err
+		}
+		book.url = stringData.value
+		book.typ = ExternalBookExternal
+		if numSheets == 0 {
+			book.typ = ExternalBookLink
+		}
+
+		// offset: var; size: var; list of sheet names (Unicode strings, 16-bit length)
+		offset := 2 + stringData.size
+		for i := 0; i < numSheets; i++ {
+			stringData, err := xls.readUnicodeStringLong(recordData[offset:])
+			if err != nil {
+				return err
+			}
+			book.sheetNames = append(book.sheetNames, stringData.value)
+			offset += stringData.size
+		}
+	}
+
+	xls.externalBooks = append(xls.externalBooks, book)
+	return nil
+}
+
+// readExternName reads an EXTERNNAME record, a name in the preceding EXTERNALBOOK.
+func (xls *XLS) readExternName() error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+
+	if xls.version != XLS_BIFF8 || len(xls.externalBooks) == 0 {
+		return nil
+	}
+	if len(recordData) < 7 {
+		return ErrTruncated
+	}
+
+	book := xls.externalBooks[len(xls.externalBooks)-1]
+	name := ExternalName{Sheet: -1}
+
+	// offset: 0; size: 2; option flags
+	options := getUInt2d(recordData, 0)
+	// bit: 0; mask: 0x0001; built-in name
+	name.BuiltIn = options&0x0001 != 0
+	// bit: 4; mask: 0x0010; OLE link
+	name.OLE = options&0x0010 != 0
+
+	// offset: 2; size: 2; for external names one-based index to the sheet, 0 for global names
+	if book.typ == ExternalBookExternal {
+		name.Sheet = int(getUInt2d(recordData, 2)) - 1
+	}
+	// offset: 4; size: 2; not used
+	// offset: 6; size: var; name (Unicode string, 8-bit length)
+	stringData, err := xls.readUnicodeStringShort(recordData[6:])
+	if err != nil {
+		return err
+	}
+	name.Name = stringData.value
+
+	book.names = append(book.names, name)
+	return nil
+}
+
+// readExternSheet reads the EXTERNSHEET record, the table of sheet ranges used by 3D references.
+func (xls *XLS) readExternSheet() error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+
+	if xls.version != XLS_BIFF8 {
+		return nil
+	}
+	if len(recordData) < 2 {
+		return ErrTruncated
+	}
+
+	// offset: 0; size: 2; number of following ref structures
+	count := int(getUInt2d(recordData, 0))
+	if 2+6*count > len(recordData) {
+		return ErrTruncated
+	}
+
+	for i := 0; i < count; i++ {
+		pos := 2 + 6*i
+		xls.externSheets = append(xls.externSheets, externSheet{
+			// offset: 0; size: 2; index to EXTERNALBOOK record
+			book: int(getUInt2d(recordData, pos)),
+			// offset: 2; size: 2; index to first sheet in EXTERNALBOOK record
+			firstSheet: int(getUInt2d(recordData, pos+2)),
+			// offset: 4; size: 2; index to last sheet in EXTERNALBOOK record
+			lastSheet: int(getUInt2d(recordData, pos+4)),
+		})
+	}
+
+	return nil
+}
+
+// readDefinedName reads a DEFINEDNAME record, a named range, constant or formula.
+func (xls *XLS) readDefinedName() error {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return err
+	}
+	if len(recordData) < 15 {
+		return ErrTruncated
+	}
+
+	name := &definedName{}
+
+	// offset: 0; size: 2; option flags
+	options := getUInt2d(recordData, 0)
+	// bit: 0; mask: 0x0001; 1 = name is hidden
+	name.hidden = options&0x0001 != 0
+	// bit: 5; mask: 0x0020; 1 = built-in name
+	name.builtIn = options&0x0020 != 0
+
+	// offset: 2; size: 1; keyboard shortcut
+	// offset: 3; size: 1; length of the name (character count)
+	nameLength := int(recordData[3])
+	// offset: 4; size: 2; size of the formula data
+	formulaSize := int(getUInt2d(recordData, 4))
+	// offset: 6; size: 2; not used
+	// offset: 8; size: 2; 0 = global name, otherwise one-based index to sheet
+	name.sheetIndex = int(getUInt2d(recordData, 8)) - 1
+	// offset: 10; size: 4; length of menu, description, help topic and status bar text
+
+	// offset: 14; size: var; name (Unicode string without length field)
+	var nameSize int
+	if xls.version == XLS_BIFF8 {
+		stringData, err := xls.readUnicodeString(recordData[14:], nameLength)
+		if err != nil {
+			return err
+		}
+		name.name = stringData.value
+		nameSize = stringData.size
+	} else {
+		// 8-bit characters
+		if 14+nameLength > len(recordData) {
+			return ErrTruncated
+		}
+		name.name = xls.decodeCodepage(string(recordData[14 : 14+nameLength]))
+		nameSize = nameLength
+	}
+	if name.builtIn && len(name.name) == 1 {
+		if builtIn, ok := builtInNames[name.name[0]]; ok {
+			name.name = builtIn
+		}
+	}
+
+	// offset: var; size: formulaSize; formula data
+	offset := 14 + nameSize
+	if offset+formulaSize > len(recordData) {
+		return ErrTruncated
+	}
+	name.formula = recordData[offset : offset+formulaSize]
+	name.extra = recordData[offset+formulaSize:]
+
+	xls.definedNames = append(xls.definedNames, name)
+	return nil
+}
+
+// externSheetPrefix renders the sheet part of a 3D reference, e.g. "Sheet2!", "'Q1 Data:Q4 Data'!"
+// or "[1]Prices!" for the first external workbook. It reports false for references to deleted sheets.
+func (xls *XLS) externSheetPrefix(index int) (string, bool) {
+	if index < 0 || index >= len(xls.externSheets) {
+		return "", false
+	}
+	ref := xls.externSheets[index]
+	if ref.book < 0 || ref.book >= len(xls.externalBooks) {
+		return "", false
+	}
+	book := xls.externalBooks[ref.book]
+
+	var names []string
+	switch book.typ {
+	case ExternalBookInternal:
+		names = make([]string, len(xls.sheets))
+		for i, sheet := range xls.sheets {
+			names[i] = sheet.name
+		}
+	case ExternalBookExternal:
+		names = book.sheetNames
+	default:
+		return "", false
+	}
+
+	if ref.firstSheet < 0 || ref.firstSheet >= len(names) || ref.lastSheet < ref.firstSheet || ref.lastSheet >= len(names) {
+		// 0xFFFF = deleted sheet, 0xFFFE = reference to the workbook
+		return "", false
+	}
+
+	prefix := quoteSheetName(names[ref.firstSheet])
+	if ref.lastSheet != ref.firstSheet {
+		prefix = quoteSheetName(names[ref.firstSheet] + ":" + names[ref.lastSheet])
+	}
+	if book.typ == ExternalBookExternal {
+		prefix = "[" + strconv.Itoa(xls.externalBookNumber(ref.book)) + "]" + prefix
+	}
+
+	return prefix + "!", true
+}
+
+// isInternalRef reports whether the EXTERNSHEET entry refers to sheets of this workbook.
+func (xls *XLS) isInternalRef(index int) bool {
+	if index < 0 || index >= len(xls.externSheets) {
+		return false
+	}
+	book := xls.externSheets[index].book
+	return book >= 0 && book < len(xls.externalBooks) && xls.externalBooks[book].typ == ExternalBookInternal
+}
+
+// externalName renders the name referred to by a tNameX token, nameIndex is zero-based.
+func (xls *XLS) externalName(index, nameIndex int) string {
+	if index < 0 || index >= len(xls.externSheets) {
+		return mapErrorCode(0x17)
+	}
+	ref := xls.externSheets[index]
+	if ref.book < 0 || ref.book >= len(xls.externalBooks) {
+		return mapErrorCode(0x17)
+	}
+	book := xls.externalBooks[ref.book]
+
+	if book.typ == ExternalBookInternal {
+		// names of this workbook
+		if nameIndex < 0 || nameIndex >= len(xls.definedNames) {
+			return mapErrorCode(0x1D)
+		}
+		return xls.definedNames[nameIndex].name
+	}
+
+	if nameIndex < 0 || nameIndex >= len(book.names) {
+		return mapErrorCode(0x1D)
+	}
+	if book.typ == ExternalBookExternal {
+		return "[" + strconv.Itoa(xls.externalBookNumber(ref.book)) + "]!" + book.names[nameIndex].Name
+	}
+	return book.names[nameIndex].Name
+}
+
+// externalBookNumber returns the one-based number of an external workbook, counting external workbooks only.
+func (xls *XLS) externalBookNumber(index int) int {
+	number := 0
+	for i := 0; i <= index && i < len(xls.externalBooks); i++ {
+		if xls.externalBooks[i].typ == ExternalBookExternal {
+			number++
+		}
+	}
+	return number
+}
+
+// getRecord reads a cell record that is at least minSize bytes long and returns its data, row and column.
+func (xls *XLS) getRecord(minSize int) ([]byte, int, int, error) {
+	recordData, err := xls.nextRecord()
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if len(recordData) < minSize {
+		return nil, 0, 0, ErrTruncated
+	}
+	row := getUInt2d(recordData, 0)
+	col := getUInt2d(recordData, 2)
+	return recordData, int(row), int(col), nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (xls *XLS) decodeCodepage(data string) string {
+	return ConvertFrom(data, xls.CodePage)
+}
+
+// readByteStringShort reads a byte string (8-bit string length) from the given data.
+func (xls *XLS) readByteStringShort(subData []byte) (*stringConvertion, error) {
+	if len(subData) < 1 {
+		return nil, ErrTruncated
+	}
+
+	// offset: 0; size: 1; length of the string (character count)
+	ln := int(subData[0])
+	if 1+ln > len(subData) {
+		return nil, ErrTruncated
+	}
+
+	// offset: 1: size: var; character array (8-bit characters)
+	value := xls.decodeCodepage(string(subData[1 : 1+ln]))
+
+	return &stringConvertion{
+		value: value,
+		size:  1 + ln,
+	}, nil
+}
+
+func (xls *XLS) readByteStringLong(subData []byte) (*stringConvertion, error) {
+	if len(subData) < 2 {
+		return nil, ErrTruncated
+	}
+
 	// offset: 0; size: 2; length of the string (character count)
 	ln := int(getUInt2d(subData, 0))
+	if 2+ln > len(subData) {
+		return nil, ErrTruncated
+	}
 
 	// offset: 2: size: var; character array (8-bit characters)
 	value := xls.decodeCodepage(string(subData[2 : 2+ln]))
@@ -712,11 +2593,15 @@ func (xls *XLS) readByteStringLong(subData []byte) *stringConvertion {
 	return &stringConvertion{
 		value: value,
 		size:  2 + ln,
-	}
+	}, nil
 }
 
 // readUnicodeString reads a Unicode string with no string length field but with a known character count.
-func (xls *XLS) readUnicodeString(subData []byte, characterCount int) *stringConvertion {
+func (xls *XLS) readUnicodeString(subData []byte, characterCount int) (*stringConvertion, error) {
+	if len(subData) < 1 {
+		return nil, ErrTruncated
+	}
+
 	// offset: 0: size: 1; option flags
 	// bit: 0; mask: 0x01; character compression (0 = compressed 8-bit, 1 = uncompressed 16-bit)
 	isCompressed := (subData[0] & 0x01) == 0
@@ -727,6 +2612,14 @@ func (xls *XLS) readUnicodeString(subData []byte, characterCount int) *stringCon
 	// bit: 3; mask: 0x08; Rich-Text settings
 	//hasRichText := (subData[0] & 0x08) >> 3
 
+	charSize := 2
+	if isCompressed {
+		charSize = 1
+	}
+	if 1+characterCount*charSize > len(subData) {
+		return nil, ErrTruncated
+	}
+
 	// offset: 1: size: var; character array
 	// this offset assumes richtext and Asian phonetic settings are off which is generally wrong
 	// needs to be fixed
@@ -743,37 +2636,45 @@ func (xls *XLS) readUnicodeString(subData []byte, characterCount int) *stringCon
 
 	return &stringConvertion{
 		value: value,
-		size: 1 + characterCount*func() int {
-			if isCompressed {
-				return 1
-			} else {
-				return 2
-			}
-		}(),
-	}
+		size:  1 + characterCount*charSize,
+	}, nil
 }
 
-func (xls *XLS) readUnicodeStringShort(subData []byte) *stringConvertion {
+func (xls *XLS) readUnicodeStringShort(subData []byte) (*stringConvertion, error) {
+	if len(subData) < 1 {
+		return nil, ErrTruncated
+	}
+
 	// offset: 0; size: 1; length of the string (character count)
 	ln := int(subData[0])
 
-	ret := xls.readUnicodeString(subData[1:], ln)
+	ret, err := xls.readUnicodeString(subData[1:], ln)
+	if err != nil {
+		return nil, err
+	}
 
 	ret.size += 1 // size in bytes of data structure
-	return ret
+	return ret, nil
 }
 
-func (xls *XLS) readUnicodeStringLong(subData []byte) *stringConvertion {
+func (xls *XLS) readUnicodeStringLong(subData []byte) (*stringConvertion, error) {
+	if len(subData) < 2 {
+		return nil, ErrTruncated
+	}
+
 	// offset: 0; size: 2; length of the string (character count)
 	ln := int(getUInt2d(subData, 0))
 
-	ret := xls.readUnicodeString(subData[2:], ln)
+	ret, err := xls.readUnicodeString(subData[2:], ln)
+	if err != nil {
+		return nil, err
+	}
 
 	ret.size += 2 // size in bytes of data structure
-	return ret
+	return ret, nil
 }
 
-func (xls *XLS) getSplicedRecordData() *sstConvertion {
+func (xls *XLS) getSplicedRecordData() (*sstConvertion, error) {
 	var data []byte
 	spliceOffsets := []int{0}
 
@@ -785,12 +2686,14 @@ func (xls *XLS) getSplicedRecordData() *sstConvertion {
 		// offset: 0; size: 2; identifier
 		//identifier := getUInt2d(xls.data, xls.pos)
 		// offset: 2; size: 2; length
-		length := getUInt2d(xls.data, xls.pos+2)
-		data = append(data, xls.data[xls.pos+4:xls.pos+4+int(length)]...)
+		recordData, err := xls.nextRecord()
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, recordData...)
 
-		spliceOffsets = append(spliceOffsets, spliceOffsets[i-1]+int(length))
+		spliceOffsets = append(spliceOffsets, spliceOffsets[i-1]+len(recordData))
 
-		xls.pos += 4 + int(length)
 		nextIdentifier := getUInt2d(xls.data, xls.pos)
 		if nextIdentifier != XLS_TYPE_CONTINUE {
 			break
@@ -800,5 +2703,5 @@ func (xls *XLS) getSplicedRecordData() *sstConvertion {
 	return &sstConvertion{
 		recordData:    data,
 		spliceOffsets: spliceOffsets,
-	}
+	}, nil
 }
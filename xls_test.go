@@ -0,0 +1,167 @@
+package xls
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func TestOpen(t *testing.T) {
+	book := &testBook{sheets: []testSheet{{name: "Data", records: []record{
+		numberRec(0, 0, 3.5),
+		labelRec(1, 1, "hello"),
+	}}}}
+	data := book.bytes()
+
+	open := map[string]func() (*XLS, error){
+		"OpenBytes":  func() (*XLS, error) { return OpenBytes(data) },
+		"OpenReader": func() (*XLS, error) { return OpenReader(bytes.NewReader(data), int64(len(data))) },
+		"OpenFS":     func() (*XLS, error) { return OpenFS(fstest.MapFS{"book.xls": {Data: data}}, "book.xls") },
+	}
+	for name, fn := range open {
+		xls, err := fn()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		sheet := xls.Sheets()[0]
+		if sheet.Name() != "Data" || sheet.Row(0).Cell(0).Value() != 3.5 || sheet.Row(1).Cell(1).Value() != "hello" {
+			t.Errorf("%s: unexpected sheet contents", name)
+		}
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	if _, err := OpenBytes([]byte("not a compound file")); err == nil {
+		t.Error("OpenBytes accepted data without OLE header")
+	}
+
+	// the LABEL record claims 50 characters but holds 2
+	book := &testBook{sheets: []testSheet{{name: "S", records: []record{
+		rec(XLS_TYPE_LABEL, 1, 1, 15, uint16(50), uint8(0), "ab"),
+	}}}}
+	_, err := OpenBytes(book.bytes())
+	var recErr *RecordError
+	if !errors.As(err, &recErr) || recErr.Type != XLS_TYPE_LABEL || !errors.Is(err, ErrTruncated) {
+		t.Errorf("truncated LABEL: got %v", err)
+	}
+
+	// records shorter than their fixed part
+	for _, typ := range []uint16{XLS_TYPE_NUMBER, XLS_TYPE_RK, XLS_TYPE_FORMULA, XLS_TYPE_LABELSST} {
+		book := &testBook{sheets: []testSheet{{name: "S", records: []record{rec(typ, 0, 0)}}}}
+		if _, err := OpenBytes(book.bytes()); !errors.Is(err, ErrTruncated) {
+			t.Errorf("record 0x%04x: got %v, want ErrTruncated", typ, err)
+		}
+	}
+
+	// a record length past the end of the stream
+	stream := (&testBook{sheets: []testSheet{{name: "S", records: []record{numberRec(0, 0, 1)}}}}).stream()
+	// the NUMBER record is followed by the 4 bytes of EOF, its length is at offset 2 of its header
+	length := len(stream) - 4 - 18 + 2
+	stream[length], stream[length+1] = 0xFF, 0xFF
+	if _, err := OpenBytes(compoundFile(stream)); err == nil {
+		t.Error("record past the end of the stream was accepted")
+	}
+}
+
+func TestSharedStrings(t *testing.T) {
+	// the second string continues in a CONTINUE record, switching to 16-bit characters
+	book := &testBook{
+		globals: []record{
+			rec(XLS_TYPE_SST, uint32(3), uint32(3), xlString("first"), uint16(11), uint8(0), "hello "),
+			rec(XLS_TYPE_CONTINUE, uint8(1), utf16Bytes("wörld"), xlString("third")),
+		},
+		sheets: []testSheet{{name: "S", records: []record{
+			rec(XLS_TYPE_LABELSST, 0, 0, 15, uint32(0)),
+			rec(XLS_TYPE_LABELSST, 0, 1, 15, uint32(1)),
+			rec(XLS_TYPE_LABELSST, 0, 2, 15, uint32(2)),
+		}}},
+	}
+	row := openTestBook(t, book).Sheets()[0].Row(0)
+	for col, want := range []string{"first", "hello wörld", "third"} {
+		if got := row.Cell(col).Value(); got != want {
+			t.Errorf("cell %d: got %q, want %q", col, got, want)
+		}
+	}
+}
+
+func TestFormulaResults(t *testing.T) {
+	result := func(typ, value uint8) []byte {
+		return pack(typ, uint8(0), value, uint8(0), uint16(0), uint16(0xFFFF))
+	}
+	formula := func(col int, res []byte) record {
+		rgce := tInt(1)
+		return rec(XLS_TYPE_FORMULA, 0, col, 15, res, uint16(0), uint32(0), uint16(len(rgce)), rgce)
+	}
+	book := &testBook{sheets: []testSheet{{name: "S", records: []record{
+		formula(0, pack(12.5)),
+		formula(1, result(0x00, 0)),
+		rec(XLS_TYPE_STRING, xlString("text")),
+		formula(2, result(0x01, 1)),
+		formula(3, result(0x02, 0x07)),
+		formula(4, result(0x03, 0)),
+		formula(5, result(0x09, 0)), // unknown result type
+		rec(XLS_TYPE_BOOLERR, 0, 6, 15, uint8(0x2A), uint8(1)),
+	}}}}
+	row := openTestBook(t, book).Sheets()[0].Row(0)
+
+	want := []struct {
+		value    interface{}
+		dataType CellDataType
+	}{
+		{12.5, CellDataTypeNumeric},
+		{"text", CellDataTypeString},
+		{uint8(1), CellDataTypeBool},
+		{"#DIV/0!", CellDataTypeError},
+		{"", CellDataTypeString},
+		{nil, CellDataTypeNull},
+		{"#N/A", CellDataTypeError},
+	}
+	for col, w := range want {
+		cell := row.Cell(col)
+		if cell == nil {
+			t.Errorf("cell %d is missing", col)
+			continue
+		}
+		if cell.Value() != w.value || cell.DataType() != w.dataType {
+			t.Errorf("cell %d: got %#v (%s), want %#v (%s)", col, cell.Value(), cell.DataType(), w.value, w.dataType)
+		}
+	}
+	if got := row.Cell(5).Formula(); got != "=1" {
+		t.Errorf("formula of the cell with unknown result: got %q", got)
+	}
+}
+
+func TestMulRK(t *testing.T) {
+	integer := func(v int32) uint32 { return uint32(v<<2) | 0x02 }
+	book := &testBook{sheets: []testSheet{{name: "S", records: []record{
+		// B2:F2 with integers, a float, a float divided by 100 and an integer divided by 100
+		rec(XLS_TYPE_MULRK, 1, 1, uint16(20), integer(5), uint16(21), integer(-7), uint16(22), uint32(0x3FF80000),
+			uint16(23), uint32(0x3FF80000|0x01), uint16(24), integer(1234)|0x01, 5),
+		rec(XLS_TYPE_MULBLANK, 2, 0, uint16(30), uint16(31), uint16(32), 2),
+		rec(XLS_TYPE_BLANK, 3, 4, 40),
+	}}}}
+	sheet := openTestBook(t, book).Sheets()[0]
+
+	for i, want := range []float64{5, -7, 1.5, 0.015, 12.34} {
+		cell := sheet.Row(1).Cell(i + 1)
+		if cell.Value() != want || cell.DataType() != CellDataTypeNumeric || cell.XFIndex() != 20+i {
+			t.Errorf("MULRK cell %d: got %#v (%s) with XF %d, want %v", i, cell.Value(), cell.DataType(), cell.XFIndex(), want)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		cell := sheet.Row(2).Cell(i)
+		if cell.Value() != nil || cell.DataType() != CellDataTypeNull || cell.XFIndex() != 30+i {
+			t.Errorf("MULBLANK cell %d: got %#v (%s) with XF %d", i, cell.Value(), cell.DataType(), cell.XFIndex())
+		}
+	}
+	if cell := sheet.Row(3).Cell(4); cell.DataType() != CellDataTypeNull || cell.XFIndex() != 40 {
+		t.Errorf("BLANK: got %s with XF %d", cell.DataType(), cell.XFIndex())
+	}
+
+	// the last column does not match the number of cells
+	book.sheets[0].records = []record{rec(XLS_TYPE_MULRK, 0, 3, uint16(20), integer(5), 2)}
+	if _, err := OpenBytes(book.bytes()); err == nil {
+		t.Error("MULRK with a wrong last column was accepted")
+	}
+}